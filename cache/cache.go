@@ -0,0 +1,208 @@
+// Package cache wraps an expertview.Client with a cache of the file list and of file bodies.
+//
+// The file list is cached for a configurable time, while file bodies are cached forever: Expert View never
+// changes a published file, it publishes a new one with a new name. Concurrent identical requests are merged
+// into a single call to the wrapped client. That call is not bound to the context of any caller: every caller
+// stops waiting when its own context is done, and the call is canceled once no caller waits for it anymore.
+package cache
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+)
+
+// Client is an expertview.Client caching GetFileList, GetFile and GetFileTo. The other operations go straight
+// to the wrapped client.
+type Client struct {
+	expertview.Client
+
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+	calls group
+}
+
+var _ expertview.Client = (*Client)(nil)
+
+// New returns a Client caching the calls to c in store. The file list is fetched again once it is older than
+// ttl; a zero ttl caches it forever (until Invalidate is called).
+func New(c expertview.Client, store Store, ttl time.Duration) *Client {
+	return &Client{
+		Client: c,
+		store:  store,
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// Invalidate makes the next GetFileList fetch the file list again.
+func (c *Client) Invalidate() error {
+	return c.store.PutFileList(expertview.FileList{}, time.Time{})
+}
+
+func (c *Client) GetFileList() (expertview.FileList, error) {
+	return c.GetFileListContext(context.Background())
+}
+
+func (c *Client) GetFileListContext(ctx context.Context) (expertview.FileList, error) {
+	fl, fetched, ok, err := c.store.GetFileList()
+	if err != nil {
+		return expertview.FileList{}, err
+	}
+	if ok && !fetched.IsZero() && (c.ttl == 0 || c.now().Sub(fetched) < c.ttl) {
+		return fl, nil
+	}
+
+	v, err := c.calls.do(ctx, "filelist", func(ctx context.Context) (interface{}, error) {
+		fl, err := c.Client.GetFileListContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// caching is best effort: the file list is fetched again next time
+		c.store.PutFileList(fl, c.now())
+		return fl, nil
+	})
+	if err != nil {
+		return expertview.FileList{}, err
+	}
+	return copyFileList(v.(expertview.FileList)), nil
+}
+
+func (c *Client) GetFile(filename string) ([]byte, error) {
+	return c.GetFileContext(context.Background(), filename)
+}
+
+func (c *Client) GetFileContext(ctx context.Context, filename string) ([]byte, error) {
+	data, ok, err := c.store.GetFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return data, nil
+	}
+
+	v, err := c.calls.do(ctx, "file:"+filename, func(ctx context.Context) (interface{}, error) {
+		data, err := c.Client.GetFileContext(ctx, filename)
+		if err != nil {
+			return nil, err
+		}
+		// caching is best effort: the file is fetched again next time
+		c.store.PutFile(filename, data)
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), v.([]byte)...), nil
+}
+
+// GetFileTo is like GetFileContext, writing the file to w. With a FileStreamer store, like DirStore, the file is
+// streamed into the store and then copied to w, never held in memory; other stores hold the whole file in memory.
+func (c *Client) GetFileTo(ctx context.Context, filename string, w io.Writer) (int64, error) {
+	fs, ok := c.store.(FileStreamer)
+	if !ok {
+		data, err := c.GetFileContext(ctx, filename)
+		if err != nil {
+			return 0, err
+		}
+		n, err := w.Write(data)
+		return int64(n), err
+	}
+
+	if n, ok, err := copyCached(fs, filename, w); ok || err != nil {
+		return n, err
+	}
+	_, err := c.calls.do(ctx, "stream:"+filename, func(ctx context.Context) (interface{}, error) {
+		var err error
+		// caching is best effort: if the store fails, the file is streamed from the wrapped client below
+		fs.PutFileFrom(filename, func(w io.Writer) error {
+			_, err = c.Client.GetFileTo(ctx, filename, w)
+			return err
+		})
+		return nil, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if n, ok, err := copyCached(fs, filename, w); ok {
+		return n, err
+	}
+	return c.Client.GetFileTo(ctx, filename, w)
+}
+
+// copyCached copies the cached contents of filename to w, reporting false if there are none.
+func copyCached(fs FileStreamer, filename string, w io.Writer) (int64, bool, error) {
+	r, ok, err := fs.OpenFile(filename)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	defer r.Close()
+	n, err := io.Copy(w, r)
+	return n, true, err
+}
+
+// group merges concurrent calls with the same key into one.
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done    chan struct{}
+	val     interface{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do runs fn once for all the concurrent callers with the same key, and waits for its result or for ctx to be
+// done. fn runs on its own context, canceled when every caller has stopped waiting.
+func (g *group) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if !ok {
+		if g.calls == nil {
+			g.calls = make(map[string]*call)
+		}
+		callCtx, cancel := context.WithCancel(context.Background())
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go func() {
+			c.val, c.err = fn(callCtx)
+			g.forget(key, c)
+			close(c.done)
+			cancel()
+		}()
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.val, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// nobody waits for the call anymore, a new caller starts a new one
+			c.cancel()
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (g *group) forget(key string, c *call) {
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+}
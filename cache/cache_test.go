@@ -0,0 +1,266 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/expertviewtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dcfRecord = expertview.Record{
+	Kind: expertview.DCFKind,
+	Name: "SQU-8000-TRKS-000000-131001CL.DCF",
+	File: "D9984527582012022715184747.dcf",
+}
+
+// countingClient counts the calls to the wrapped client, and makes them wait for release if it is not nil.
+type countingClient struct {
+	expertview.Client
+	fileLists int32
+	files     int32
+	streams   int32
+	release   chan struct{}
+}
+
+func (c *countingClient) GetFileListContext(ctx context.Context) (expertview.FileList, error) {
+	atomic.AddInt32(&c.fileLists, 1)
+	if c.release != nil {
+		<-c.release
+	}
+	return c.Client.GetFileListContext(ctx)
+}
+
+func (c *countingClient) GetFileContext(ctx context.Context, filename string) ([]byte, error) {
+	atomic.AddInt32(&c.files, 1)
+	if c.release != nil {
+		<-c.release
+	}
+	return c.Client.GetFileContext(ctx, filename)
+}
+
+func (c *countingClient) GetFileTo(ctx context.Context, filename string, w io.Writer) (int64, error) {
+	atomic.AddInt32(&c.streams, 1)
+	return c.Client.GetFileTo(ctx, filename, w)
+}
+
+func newCountingClient() *countingClient {
+	fake := expertviewtest.NewFake()
+	fake.AddFile(dcfRecord, []byte("hello"))
+	return &countingClient{Client: fake}
+}
+
+func testStores(t *testing.T) map[string]Store {
+	ds, err := NewDirStore(t.TempDir())
+	require.Nil(t, err)
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"dir":    ds,
+	}
+}
+
+func TestClient_GetFileList(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			cc := newCountingClient()
+			c := New(cc, store, time.Minute)
+			now := time.Date(2016, 8, 25, 0, 0, 0, 0, time.UTC)
+			c.now = func() time.Time { return now }
+
+			fl, err := c.GetFileList()
+			require.Nil(t, err)
+			assert.Equal(t, []expertview.Record{dcfRecord}, fl.Records)
+			_, err = c.GetFileList()
+			require.Nil(t, err)
+			assert.Equal(t, int32(1), cc.fileLists)
+
+			now = now.Add(time.Minute)
+			_, err = c.GetFileList()
+			require.Nil(t, err)
+			assert.Equal(t, int32(2), cc.fileLists)
+
+			require.Nil(t, c.Invalidate())
+			_, err = c.GetFileList()
+			require.Nil(t, err)
+			assert.Equal(t, int32(3), cc.fileLists)
+		})
+	}
+}
+
+func TestClient_GetFile(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			cc := newCountingClient()
+			c := New(cc, store, time.Minute)
+
+			for i := 0; i < 3; i++ {
+				data, err := c.GetFile(dcfRecord.File)
+				require.Nil(t, err)
+				assert.Equal(t, []byte("hello"), data)
+			}
+			assert.Equal(t, int32(1), cc.files)
+
+			_, err := c.GetFile("D0000000000000000000000000.dcf")
+			assert.Equal(t, expertview.ErrNoSuchFile, err)
+			_, err = c.GetFile("D0000000000000000000000000.dcf")
+			assert.Equal(t, expertview.ErrNoSuchFile, err)
+			assert.Equal(t, int32(3), cc.files)
+		})
+	}
+}
+
+func TestClient_MergesConcurrentCalls(t *testing.T) {
+	cc := newCountingClient()
+	cc.release = make(chan struct{})
+	c := New(cc, NewMemoryStore(), time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := c.GetFile(dcfRecord.File)
+			assert.Nil(t, err)
+			assert.Equal(t, []byte("hello"), data)
+		}()
+	}
+	// let the calls pile up on the first one
+	for atomic.LoadInt32(&cc.files) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(cc.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cc.files))
+}
+
+func TestDirStore_ContentAddressed(t *testing.T) {
+	dir := t.TempDir()
+	ds, err := NewDirStore(dir)
+	require.Nil(t, err)
+	require.Nil(t, ds.PutFile("a.dcf", []byte("same")))
+	require.Nil(t, ds.PutFile("b.dcf", []byte("same")))
+
+	ds, err = NewDirStore(dir)
+	require.Nil(t, err)
+	data, ok, err := ds.GetFile("b.dcf")
+	require.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("same"), data)
+	blobs, err := filepath.Glob(filepath.Join(dir, "blobs", "*", "*"))
+	require.Nil(t, err)
+	assert.Len(t, blobs, 1)
+}
+
+// waitForWaiters waits until n callers wait for the call with the given key.
+func waitForWaiters(c *Client, key string, n int) {
+	for {
+		c.calls.mu.Lock()
+		call := c.calls.calls[key]
+		waiting := call != nil && call.waiters == n
+		c.calls.mu.Unlock()
+		if waiting {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClient_MergedCallsHonorCallerContext(t *testing.T) {
+	cc := newCountingClient()
+	cc.release = make(chan struct{})
+	c := New(cc, NewMemoryStore(), time.Minute)
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := c.GetFileContext(first, dcfRecord.File)
+		firstErr <- err
+	}()
+	for atomic.LoadInt32(&cc.files) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	second := make(chan []byte)
+	go func() {
+		data, err := c.GetFileContext(context.Background(), dcfRecord.File)
+		assert.Nil(t, err)
+		second <- data
+	}()
+	waitForWaiters(c, "file:"+dcfRecord.File, 2)
+
+	// a waiter with a deadline gives up on its own
+	ctx, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+	_, err := c.GetFileContext(ctx, dcfRecord.File)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// the first caller giving up does not cancel the call for the others
+	cancel()
+	assert.Equal(t, context.Canceled, <-firstErr)
+	close(cc.release)
+	assert.Equal(t, []byte("hello"), <-second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cc.files))
+}
+
+// failingStore is an empty store that cannot be written.
+type failingStore struct {
+	Store
+}
+
+func (failingStore) PutFileList(expertview.FileList, time.Time) error {
+	return errors.New("disk full")
+}
+
+func (failingStore) PutFile(string, []byte) error {
+	return errors.New("disk full")
+}
+
+func TestClient_StoreErrorsAreBestEffort(t *testing.T) {
+	cc := newCountingClient()
+	c := New(cc, failingStore{NewMemoryStore()}, time.Minute)
+
+	fl, err := c.GetFileList()
+	require.Nil(t, err)
+	assert.Equal(t, []expertview.Record{dcfRecord}, fl.Records)
+
+	data, err := c.GetFile(dcfRecord.File)
+	require.Nil(t, err)
+	assert.Equal(t, []byte("hello"), data)
+}
+
+func TestClient_GetFileToStreams(t *testing.T) {
+	ds, err := NewDirStore(t.TempDir())
+	require.Nil(t, err)
+	cc := newCountingClient()
+	c := New(cc, ds, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		var buf bytes.Buffer
+		n, err := c.GetFileTo(context.Background(), dcfRecord.File, &buf)
+		require.Nil(t, err)
+		assert.Equal(t, int64(5), n)
+		assert.Equal(t, "hello", buf.String())
+	}
+	assert.Equal(t, int32(1), cc.streams)
+	assert.Equal(t, int32(0), cc.files)
+
+	data, err := c.GetFile(dcfRecord.File)
+	require.Nil(t, err)
+	assert.Equal(t, []byte("hello"), data)
+	assert.Equal(t, int32(0), cc.files)
+
+	_, err = c.GetFileTo(context.Background(), "D0000000000000000000000000.dcf", ioutil.Discard)
+	assert.Equal(t, expertview.ErrNoSuchFile, err)
+}
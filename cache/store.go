@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/internal/atomicfile"
+)
+
+// Store is a cache back end. File bodies are kept forever, addressed by the SHA-256 of their contents, so files
+// with the same contents are only stored once.
+type Store interface {
+	// GetFileList returns the cached file list and the time it was fetched, or ok false if there is none.
+	GetFileList() (fl expertview.FileList, fetched time.Time, ok bool, err error)
+	PutFileList(fl expertview.FileList, fetched time.Time) error
+	// GetFile returns the cached contents of filename, or ok false if there are none.
+	GetFile(filename string) (data []byte, ok bool, err error)
+	PutFile(filename string, data []byte) error
+}
+
+// FileStreamer is a Store that can also stream file bodies, so Client.GetFileTo keeps memory use flat for large
+// files.
+type FileStreamer interface {
+	Store
+	// OpenFile returns the cached contents of filename, or ok false if there are none.
+	OpenFile(filename string) (r io.ReadCloser, ok bool, err error)
+	// PutFileFrom stores the contents of filename written by write. Nothing is stored if write fails.
+	PutFileFrom(filename string, write func(w io.Writer) error) error
+}
+
+var _ FileStreamer = (*DirStore)(nil)
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryStore is a Store keeping everything in memory.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	fileList *expertview.FileList
+	fetched  time.Time
+	names    map[string]string
+	blobs    map[string][]byte
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		names: make(map[string]string),
+		blobs: make(map[string][]byte),
+	}
+}
+
+func (s *MemoryStore) GetFileList() (expertview.FileList, time.Time, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.fileList == nil {
+		return expertview.FileList{}, time.Time{}, false, nil
+	}
+	return copyFileList(*s.fileList), s.fetched, true, nil
+}
+
+func (s *MemoryStore) PutFileList(fl expertview.FileList, fetched time.Time) error {
+	fl = copyFileList(fl)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fileList, s.fetched = &fl, fetched
+	return nil
+}
+
+func (s *MemoryStore) GetFile(filename string) ([]byte, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.blobs[s.names[filename]]
+	if !ok {
+		return nil, false, nil
+	}
+	return append([]byte(nil), data...), true, nil
+}
+
+func (s *MemoryStore) PutFile(filename string, data []byte) error {
+	hash := contentHash(data)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.blobs[hash]; !ok {
+		s.blobs[hash] = append([]byte(nil), data...)
+	}
+	s.names[filename] = hash
+	return nil
+}
+
+// DirStore is a Store keeping everything in a directory: file bodies under blobs/, named by their hash, the
+// hash of every file name under files/, and the file list in filelist.json. Every file is written atomically, so
+// a DirStore can be shared by several processes. It is a FileStreamer.
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore returns a DirStore in dir, creating it if needed.
+func NewDirStore(dir string) (*DirStore, error) {
+	for _, sub := range []string{"blobs", "files"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &DirStore{dir: dir}, nil
+}
+
+type fileListJSON struct {
+	Fetched  time.Time           `json:"fetched"`
+	FileList expertview.FileList `json:"fileList"`
+}
+
+func (s *DirStore) GetFileList() (expertview.FileList, time.Time, bool, error) {
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, "filelist.json"))
+	if os.IsNotExist(err) {
+		return expertview.FileList{}, time.Time{}, false, nil
+	}
+	if err != nil {
+		return expertview.FileList{}, time.Time{}, false, err
+	}
+	var flj fileListJSON
+	if err = json.Unmarshal(b, &flj); err != nil {
+		return expertview.FileList{}, time.Time{}, false, err
+	}
+	return flj.FileList, flj.Fetched, true, nil
+}
+
+func (s *DirStore) PutFileList(fl expertview.FileList, fetched time.Time) error {
+	b, err := json.Marshal(fileListJSON{Fetched: fetched, FileList: fl})
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(filepath.Join(s.dir, "filelist.json"), b)
+}
+
+func (s *DirStore) GetFile(filename string) ([]byte, bool, error) {
+	hash, err := ioutil.ReadFile(s.namePath(filename))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := ioutil.ReadFile(s.blobPath(string(hash)))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if contentHash(data) != string(hash) {
+		// corrupted blob, fetch it again
+		return nil, false, nil
+	}
+	return data, true, nil
+}
+
+func (s *DirStore) PutFile(filename string, data []byte) error {
+	hash := contentHash(data)
+	blob := s.blobPath(hash)
+	if _, err := os.Stat(blob); os.IsNotExist(err) {
+		if err = os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+			return err
+		}
+		if err = atomicfile.Write(blob, data); err != nil {
+			return err
+		}
+	}
+	return atomicfile.Write(s.namePath(filename), []byte(hash))
+}
+
+func (s *DirStore) OpenFile(filename string) (io.ReadCloser, bool, error) {
+	hash, err := ioutil.ReadFile(s.namePath(filename))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	f, err := os.Open(s.blobPath(string(hash)))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	// check the blob before handing out any of it
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		f.Close()
+		return nil, false, err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != string(hash) {
+		// corrupted blob, fetch it again
+		f.Close()
+		return nil, false, nil
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, false, err
+	}
+	return f, true, nil
+}
+
+func (s *DirStore) PutFileFrom(filename string, write func(w io.Writer) error) error {
+	tmp, err := atomicfile.Create(filepath.Join(s.dir, "blobs"))
+	if err != nil {
+		return err
+	}
+	defer tmp.Abort()
+	h := sha256.New()
+	if err = write(io.MultiWriter(tmp, h)); err != nil {
+		return err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	blob := s.blobPath(hash)
+	if _, err = os.Stat(blob); os.IsNotExist(err) {
+		if err = os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+			return err
+		}
+		if err = tmp.Commit(blob); err != nil {
+			return err
+		}
+	}
+	return atomicfile.Write(s.namePath(filename), []byte(hash))
+}
+
+func (s *DirStore) blobPath(hash string) string {
+	return filepath.Join(s.dir, "blobs", hash[:2], hash)
+}
+
+func (s *DirStore) namePath(filename string) string {
+	return filepath.Join(s.dir, "files", url.PathEscape(filename))
+}
+
+func copyFileList(fl expertview.FileList) expertview.FileList {
+	return expertview.FileList{
+		DeviceTypes: append([]expertview.DeviceType(nil), fl.DeviceTypes...),
+		Records:     append([]expertview.Record(nil), fl.Records...),
+	}
+}
@@ -0,0 +1,26 @@
+package expertview
+
+import (
+	"context"
+	"io"
+)
+
+// Client is the set of Expert View operations implemented by *ExpertView. Code that depends on Client instead of
+// *ExpertView can be tested with the fake in the expertviewtest package.
+type Client interface {
+	GetFileList() (FileList, error)
+	GetFileListContext(ctx context.Context) (FileList, error)
+	GetFile(filename string) ([]byte, error)
+	GetFileContext(ctx context.Context, filename string) ([]byte, error)
+	GetFileTo(ctx context.Context, filename string, w io.Writer) (int64, error)
+	GetInstallationRecords() ([]InstallationRecord, error)
+	GetInstallationRecordsContext(ctx context.Context) ([]InstallationRecord, error)
+	PostInstallRecords(records []InstallationRecord) error
+	PostInstallRecordsContext(ctx context.Context, records []InstallationRecord) error
+	GetVersion() (Version, error)
+	GetVersionContext(ctx context.Context) (Version, error)
+	PutLogBooks(logBooks []LogBook) ([]LogBookResult, error)
+	PutLogBooksContext(ctx context.Context, logBooks []LogBook) ([]LogBookResult, error)
+}
+
+var _ Client = (*ExpertView)(nil)
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/export"
+	"github.com/larixsource/go-expertview/fleet"
+	"github.com/larixsource/go-expertview/importer"
+	"github.com/larixsource/go-expertview/internal/atomicfile"
+	"github.com/larixsource/go-expertview/snapshot"
+)
+
+func runFiles(e *env, args []string) error {
+	if len(args) != 0 {
+		return usageError{"files takes no arguments"}
+	}
+	fl, err := e.client.GetFileListContext(e.ctx)
+	if err != nil {
+		return err
+	}
+
+	if e.format == "json" {
+		return e.printJSON(fl)
+	}
+	rows := make([][]string, 0, len(fl.Records))
+	for _, rec := range fl.Records {
+		rows = append(rows, []string{string(rec.Kind), rec.Name, rec.File})
+	}
+	return e.printTable([]string{"KIND", "NAME", "FILE"}, rows)
+}
+
+func runGet(e *env, args []string) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	out := fs.String("o", "", "write the file to `path` instead of the standard output")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() != 1 {
+		return usageError{"get takes one filename"}
+	}
+	filename := fs.Arg(0)
+
+	if *out == "" || *out == "-" {
+		_, err := e.client.GetFileTo(e.ctx, filename, e.stdout)
+		return err
+	}
+
+	// download next to the destination, so it is only replaced by a complete file
+	var n int64
+	err := atomicfile.WriteFile(*out, func(w io.Writer) error {
+		var err error
+		n, err = e.client.GetFileTo(e.ctx, filename, w)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "%s: %d bytes written to %s\n", filename, n, *out)
+	return nil
+}
+
+func runRecords(e *env, args []string) error {
+	if len(args) != 0 {
+		switch args[0] {
+		case "snapshot":
+			return runRecordsSnapshot(e, args[1:])
+		case "diff":
+			return runRecordsDiff(e, args[1:])
+		case "history":
+			return runRecordsHistory(e, args[1:])
+		}
+		return usageError{fmt.Sprintf("unknown records command %q", args[0])}
+	}
+	records, err := e.client.GetInstallationRecordsContext(e.ctx)
+	if err != nil {
+		return err
+	}
+
+	if e.format == "json" {
+		return e.printJSON(records)
+	}
+	return e.printTable(recordsHeader, recordsRows(records))
+}
+
+var recordsHeader = []string{"SERIAL", "ID", "TELEMATIC", "HARDWARE", "SOFTWARE", "DCF", "FIRMWARE", "KEY", "USERNAME"}
+
+func recordsRows(records []expertview.InstallationRecord) [][]string {
+	rows := make([][]string, 0, len(records))
+	for _, rec := range records {
+		rows = append(rows, []string{
+			rec.SerialNumber,
+			rec.ID,
+			rec.Telematic,
+			rec.HardwareProf,
+			rec.SoftwareProf,
+			rec.DCF,
+			rec.Firmware,
+			rec.Key,
+			rec.Username,
+		})
+	}
+	return rows
+}
+
+const defaultSnapshotDir = "snapshots"
+
+func runRecordsSnapshot(e *env, args []string) error {
+	fs := flag.NewFlagSet("records snapshot", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	dir := fs.String("dir", defaultSnapshotDir, "snapshot `directory`")
+	every := fs.Duration("every", 0, "keep taking a snapshot every `interval`, until interrupted")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() != 0 {
+		return usageError{"records snapshot takes no arguments"}
+	}
+	st, err := snapshot.NewStore(*dir)
+	if err != nil {
+		return err
+	}
+	if *every > 0 {
+		r := &snapshot.Recorder{
+			Client:   e.client,
+			Store:    st,
+			Interval: *every,
+			OnError: func(err error) {
+				fmt.Fprintf(e.stderr, "expertview: %s\n", err)
+			},
+		}
+		if err = r.Run(e.ctx); err != context.Canceled {
+			return err
+		}
+		return nil
+	}
+	s, err := snapshot.Take(e.ctx, e.client)
+	if err != nil {
+		return err
+	}
+	if err = st.Save(s); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "%d units saved to %s at %s\n", len(s.Records), *dir, s.Taken.Format(time.RFC3339))
+	return nil
+}
+
+func runRecordsDiff(e *env, args []string) error {
+	fs := flag.NewFlagSet("records diff", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	dir := fs.String("dir", defaultSnapshotDir, "snapshot `directory`")
+	save := fs.Bool("save", false, "save the current records as a snapshot when comparing with them")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() > 2 {
+		return usageError{"records diff takes at most two times"}
+	}
+	var times []time.Time
+	for _, arg := range fs.Args() {
+		t, err := parseTime(arg)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		times = append(times, t)
+	}
+
+	st, err := snapshot.NewStore(*dir)
+	if err != nil {
+		return err
+	}
+	var from, to *snapshot.Snapshot
+	if len(times) > 0 {
+		from, err = st.At(times[0])
+	} else {
+		from, err = st.Latest()
+	}
+	if err == snapshot.ErrNoSnapshot {
+		return fmt.Errorf("no snapshot to compare with in %s", *dir)
+	}
+	if err != nil {
+		return err
+	}
+	if len(times) > 1 {
+		to, err = st.At(times[1])
+		if err != nil {
+			return err
+		}
+	} else {
+		to, err = snapshot.Take(e.ctx, e.client)
+		if err != nil {
+			return err
+		}
+		if *save {
+			if err = st.Save(to); err != nil {
+				return err
+			}
+		}
+	}
+
+	d := snapshot.Compare(from, to)
+	if e.format == "json" {
+		return e.printJSON(d)
+	}
+	var rows [][]string
+	for _, ir := range d.Added {
+		rows = append(rows, []string{"added", ir.SerialNumber, "", "", ""})
+	}
+	for _, ir := range d.Removed {
+		rows = append(rows, []string{"removed", ir.SerialNumber, "", "", ""})
+	}
+	for _, c := range d.Changed {
+		for _, f := range c.Fields {
+			rows = append(rows, []string{"changed", c.SerialNumber, string(f.Field), f.From, f.To})
+		}
+	}
+	return e.printTable([]string{"CHANGE", "SERIAL", "FIELD", "FROM", "TO"}, rows)
+}
+
+func runRecordsHistory(e *env, args []string) error {
+	fs := flag.NewFlagSet("records history", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	dir := fs.String("dir", defaultSnapshotDir, "snapshot `directory`")
+	at := fs.String("at", "", "show the record of the unit at this `time` instead of its timeline")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() != 1 {
+		return usageError{"records history takes one serial number"}
+	}
+
+	st, err := snapshot.NewStore(*dir)
+	if err != nil {
+		return err
+	}
+	h, err := st.History(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *at != "" {
+		t, err := parseTime(*at)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		ir, ok := h.At(t)
+		if !ok {
+			return fmt.Errorf("unit %s not installed at %s", h.SerialNumber, t.Format(time.RFC3339))
+		}
+		if e.format == "json" {
+			return e.printJSON(ir)
+		}
+		return e.printTable(recordsHeader, recordsRows([]expertview.InstallationRecord{ir}))
+	}
+
+	if e.format == "json" {
+		return e.printJSON(h)
+	}
+	rows := make([][]string, 0, len(h.Events))
+	for _, ev := range h.Events {
+		previous := ""
+		if ev.Previous != nil {
+			previous = ev.Previous.Format(time.RFC3339)
+		}
+		rows = append(rows, []string{
+			ev.Time.Format(time.RFC3339),
+			previous,
+			string(ev.Kind),
+			ev.Record.DCF,
+			ev.Record.Firmware,
+			changedFields(ev.Fields),
+		})
+	}
+	return e.printTable([]string{"TIME", "PREVIOUS", "EVENT", "DCF", "FIRMWARE", "CHANGED"}, rows)
+}
+
+func changedFields(fields []snapshot.FieldChange) string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = string(f.Field)
+	}
+	return strings.Join(names, ",")
+}
+
+// parseTime parses an RFC 3339 time, or a date, taken as the end of that day in UTC.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use 2006-01-02 or 2006-01-02T15:04:05Z07:00", s)
+	}
+	return t.Add(24*time.Hour - time.Nanosecond), nil
+}
+
+func runVersion(e *env, args []string) error {
+	if len(args) != 0 {
+		return usageError{"version takes no arguments"}
+	}
+	v, err := e.client.GetVersionContext(e.ctx)
+	if err != nil {
+		return err
+	}
+
+	if e.format == "json" {
+		return e.printJSON(map[string]string{
+			"server": v.String(),
+			"client": e.client.Version(),
+		})
+	}
+	return e.printTable([]string{"SERVER", "CLIENT"}, [][]string{{v.String(), e.client.Version()}})
+}
+
+func runDrift(e *env, args []string) error {
+	fs := flag.NewFlagSet("drift", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	beta := fs.Bool("beta", false, "compare with the newest release of every family, beta DCFs included")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() != 0 {
+		return usageError{"drift takes no arguments"}
+	}
+	findings, err := fleet.CheckDrift(e.ctx, e.client, fleet.DriftOptions{IncludeBeta: *beta})
+	if err != nil {
+		return err
+	}
+
+	if e.format == "json" {
+		if findings == nil {
+			findings = []fleet.Finding{}
+		}
+		return e.printJSON(findings)
+	}
+	rows := make([][]string, 0, len(findings))
+	for _, f := range findings {
+		rows = append(rows, []string{f.SerialNumber, string(f.Kind), f.Family, string(f.Status), f.Installed, f.Latest})
+	}
+	return e.printTable([]string{"SERIAL", "KIND", "FAMILY", "STATUS", "INSTALLED", "LATEST"}, rows)
+}
+
+func runImport(e *env, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	dryRun := fs.Bool("n", false, "dry run: validate and print the INSTALLATIONS XML instead of posting it")
+	input := fs.String("input", "", "input `format`: csv, json or ndjson (default from the file extension, or csv)")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() != 1 {
+		return usageError{"import takes one file"}
+	}
+	path := fs.Arg(0)
+	format := export.Format(*input)
+	if format == "" {
+		format = export.Format(strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."))
+		if format != export.JSON && format != export.NDJSON {
+			format = export.CSV
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := export.NewReader(format, f)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+
+	im := &importer.Importer{Client: e.client}
+	if *dryRun {
+		im.DryRun = e.stdout
+	}
+	n, err := im.Import(e.ctx, r)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		fmt.Fprintf(e.stderr, "%s: %d records valid, nothing posted\n", path, n)
+	} else {
+		fmt.Fprintf(e.stderr, "%s: %d records posted\n", path, n)
+	}
+	return nil
+}
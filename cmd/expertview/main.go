@@ -0,0 +1,183 @@
+// Command expertview calls the Squarell Expert View webservice from the command line.
+//
+// Usage:
+//
+//	expertview [flags] <command> [arguments]
+//
+// The commands are:
+//
+//	drift [-beta]     list the units not running the newest DCF or firmware
+//	files             list the DCF and firmware files available
+//	get <filename>    download a file
+//	import <file>     validate and post installation records from CSV or JSON
+//	records           list the installation records
+//	records snapshot  save the installation records to a snapshot directory
+//	records diff      show what changed in the installation records since a snapshot
+//	records history   show the installation timeline of a unit, from the snapshots
+//	version           show the API version of the server
+//
+// Credentials are taken from the -login and -password flags, or from the EXPERTVIEW_LOGIN and EXPERTVIEW_PASSWORD
+// environment variables. The endpoint can be set with -endpoint or EXPERTVIEW_ENDPOINT.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+)
+
+// clientOptions are added to the options of every client, for tests.
+var clientOptions []expertview.Option
+
+// env holds what a command needs to run.
+type env struct {
+	ctx    context.Context
+	client *expertview.ExpertView
+	format string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	usage string
+	run   func(e *env, args []string) error
+}
+
+var commands = map[string]command{
+	"drift":   {"drift [-beta]", runDrift},
+	"files":   {"files", runFiles},
+	"get":     {"get [-o file] <filename>", runGet},
+	"import":  {"import [-n] [-input csv|json|ndjson] <file>", runImport},
+	"records": {"records [snapshot [-dir dir] [-every interval] | diff [-dir dir] [-save] [from [to]] | history [-dir dir] [-at time] <serial>]", runRecords},
+	"version": {"version", runVersion},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr, os.Getenv)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	fs := flag.NewFlagSet("expertview", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	// the defaults from the environment are applied after parsing, so usage never prints them
+	endpoint := fs.String("endpoint", "", "webservice `url` (default $EXPERTVIEW_ENDPOINT or "+expertview.DefaultEndpoint+")")
+	login := fs.String("login", "", "login (default $EXPERTVIEW_LOGIN)")
+	password := fs.String("password", "", "password (default $EXPERTVIEW_PASSWORD)")
+	apiVersion := fs.String("api-version", expertview.DefaultVersion, "API `version` sent to the server")
+	timeout := fs.Duration("timeout", time.Minute, "timeout of every call")
+	format := fs.String("format", "table", "output `format`: table, json or csv")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: expertview [flags] <command> [arguments]\n\ncommands:\n")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(stderr, "  %s\n", commands[name].usage)
+		}
+		fmt.Fprintf(stderr, "\nflags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	for _, v := range []struct {
+		value *string
+		key   string
+	}{
+		{endpoint, "EXPERTVIEW_ENDPOINT"},
+		{login, "EXPERTVIEW_LOGIN"},
+		{password, "EXPERTVIEW_PASSWORD"},
+	} {
+		if *v.value == "" {
+			*v.value = getenv(v.key)
+		}
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "expertview: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return 2
+	}
+	if *format != "table" && *format != "json" && *format != "csv" {
+		fmt.Fprintf(stderr, "expertview: unknown format %q\n", *format)
+		return 2
+	}
+
+	opts := append([]expertview.Option{expertview.WithTimeout(*timeout)}, clientOptions...)
+	client, err := expertview.NewExpertView(*endpoint, *apiVersion, expertview.Credentials{
+		Login:    *login,
+		Password: *password,
+	}, opts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "expertview: %s\n", err)
+		return 2
+	}
+
+	e := &env{
+		ctx:    ctx,
+		client: client,
+		format: *format,
+		stdout: stdout,
+		stderr: stderr,
+	}
+	err = cmd.run(e, fs.Args()[1:])
+	var ue usageError
+	switch {
+	case errors.As(err, &ue):
+		fmt.Fprintf(stderr, "expertview: %s\nusage: expertview [flags] %s\n", ue.msg, cmd.usage)
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "expertview: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+// printJSON writes v as indented JSON.
+func (e *env) printJSON(v interface{}) error {
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printTable writes a header and rows as tab aligned columns, or as CSV with -format csv.
+func (e *env) printTable(header []string, rows [][]string) error {
+	if e.format == "csv" {
+		return csv.NewWriter(e.stdout).WriteAll(append([][]string{header}, rows...))
+	}
+	tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/expertviewtest"
+	"github.com/larixsource/go-expertview/fleet"
+	"github.com/larixsource/go-expertview/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCredentials = expertview.Credentials{
+	Login:    "demo",
+	Password: "demo",
+}
+
+func newTestServer(t *testing.T) *expertviewtest.Server {
+	server := expertviewtest.NewServer(testCredentials)
+	server.Fake.AddFile(expertview.Record{
+		Kind: expertview.DCFKind,
+		Name: "SQU-8000-TRKS-000000-131001CL.DCF",
+		File: "D9984527582012022715184747.dcf",
+	}, []byte("hello"))
+	server.Fake.SetInstallationRecords([]expertview.InstallationRecord{{
+		SerialNumber: "296930501",
+		ID:           "667769",
+		HardwareProf: "FLX12",
+		SoftwareProf: "FLEX-256-V2",
+		DCF:          "SQU-8000-TRKS-000000-131001CL.DCF",
+		Firmware:     "8000-01V114R048.BIN",
+	}})
+
+	clientOptions = []expertview.Option{expertview.WithHTTPClient(server.Client())}
+	t.Cleanup(func() {
+		clientOptions = nil
+		server.Close()
+	})
+	return server
+}
+
+// runTest runs the command with the server endpoint and credentials in the environment.
+func runTest(server *expertviewtest.Server, args ...string) (code int, stdout string, stderr string) {
+	environ := map[string]string{
+		"EXPERTVIEW_ENDPOINT": server.URL,
+		"EXPERTVIEW_LOGIN":    testCredentials.Login,
+		"EXPERTVIEW_PASSWORD": testCredentials.Password,
+	}
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), args, &out, &errOut, func(key string) string {
+		return environ[key]
+	})
+	return code, out.String(), errOut.String()
+}
+
+func TestFiles(t *testing.T) {
+	server := newTestServer(t)
+
+	code, stdout, stderr := runTest(server, "files")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "KIND  NAME                               FILE\n"+
+		"DCF   SQU-8000-TRKS-000000-131001CL.DCF  D9984527582012022715184747.dcf\n", stdout)
+
+	code, stdout, stderr = runTest(server, "-format", "json", "files")
+	require.Equal(t, 0, code, stderr)
+	var fl expertview.FileList
+	require.Nil(t, json.Unmarshal([]byte(stdout), &fl))
+	assert.Len(t, fl.Records, 1)
+}
+
+func TestGet(t *testing.T) {
+	server := newTestServer(t)
+
+	code, stdout, stderr := runTest(server, "get", "D9984527582012022715184747.dcf")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "hello", stdout)
+
+	out := filepath.Join(t.TempDir(), "file.dcf")
+	code, _, stderr = runTest(server, "get", "-o", out, "D9984527582012022715184747.dcf")
+	require.Equal(t, 0, code, stderr)
+	b, err := ioutil.ReadFile(out)
+	require.Nil(t, err)
+	assert.Equal(t, []byte("hello"), b)
+
+	code, _, stderr = runTest(server, "get", "D0000000000000000000000000.dcf")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "NoSuchFileException")
+
+	code, _, _ = runTest(server, "get")
+	assert.Equal(t, 2, code)
+}
+
+func TestRecords(t *testing.T) {
+	server := newTestServer(t)
+
+	code, stdout, stderr := runTest(server, "records")
+	require.Equal(t, 0, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "SERIAL     ID"))
+	assert.Contains(t, lines[1], "8000-01V114R048.BIN")
+}
+
+func TestVersion(t *testing.T) {
+	server := newTestServer(t)
+	server.Fake.SetVersion(expertview.Version{Major: 2, Minor: 6, Patch: 1})
+
+	code, stdout, stderr := runTest(server, "-format", "json", "version")
+	require.Equal(t, 0, code, stderr)
+	assert.JSONEq(t, `{"server": "2.6.1", "client": "2.5.0"}`, stdout)
+}
+
+func TestUsage(t *testing.T) {
+	server := newTestServer(t)
+
+	code, _, stderr := runTest(server)
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "usage: expertview")
+
+	code, _, stderr = runTest(server, "nope")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown command "nope"`)
+
+	code, _, stderr = runTest(server, "-password", "wrong", "files")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "Login failed")
+}
+
+func TestUsage_HidesEnvironment(t *testing.T) {
+	environ := map[string]string{
+		"EXPERTVIEW_LOGIN":    "admin",
+		"EXPERTVIEW_PASSWORD": "hunter2",
+	}
+	for _, args := range [][]string{{"bogus"}, {"-h"}, {"-format", "xml", "files"}} {
+		var out, errOut bytes.Buffer
+		code := run(context.Background(), args, &out, &errOut, func(key string) string {
+			return environ[key]
+		})
+		assert.Equal(t, 2, code, args)
+		assert.NotContains(t, errOut.String(), "hunter2", args)
+		assert.NotContains(t, errOut.String(), `(default "admin")`, args)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	server := newTestServer(t)
+
+	code, stdout, stderr := runTest(server, "-format", "csv", "drift")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "SERIAL,KIND,FAMILY,STATUS,INSTALLED,LATEST\n"+
+		"296930501,Firmware,8000-01,not-in-catalogue,8000-01V114R048.BIN,\n", stdout)
+
+	code, stdout, stderr = runTest(server, "-format", "json", "drift")
+	require.Equal(t, 0, code, stderr)
+	var findings []fleet.Finding
+	require.Nil(t, json.Unmarshal([]byte(stdout), &findings))
+	require.Len(t, findings, 1)
+	assert.Equal(t, fleet.NotInCatalogue, findings[0].Status)
+}
+
+func TestImport(t *testing.T) {
+	server := newTestServer(t)
+	server.Fake.AddFile(expertview.Record{
+		Kind: expertview.FirmwareKind,
+		Name: "8000-01V114R048.BIN",
+		File: "F0000000000000000000000001.bin",
+	}, []byte("firmware"))
+	path := filepath.Join(t.TempDir(), "records.csv")
+	require.Nil(t, ioutil.WriteFile(path, []byte("serialNumber,dcf,firmware\n"+
+		"296930502,SQU-8000-TRKS-000000-131001CL.DCF,8000-01V114R048.BIN\n"), 0644))
+
+	code, stdout, stderr := runTest(server, "import", "-n", path)
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, `<INSTALLATIONS><RECORD SN="296930502">`)
+	assert.NotContains(t, stdout, "<password>")
+	assert.Contains(t, stderr, "1 records valid, nothing posted")
+
+	code, _, stderr = runTest(server, "import", path)
+	require.Equal(t, 0, code, stderr)
+	records, err := server.Fake.GetInstallationRecords()
+	require.Nil(t, err)
+	assert.Len(t, records, 2)
+
+	require.Nil(t, ioutil.WriteFile(path, []byte("serialNumber,dcf,firmware\n,x.DCF,y.BIN\n"), 0644))
+	code, _, stderr = runTest(server, "import", path)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "row 2: serial number required")
+}
+
+func TestRecordsDiff(t *testing.T) {
+	server := newTestServer(t)
+	dir := filepath.Join(t.TempDir(), "snapshots")
+
+	code, _, stderr := runTest(server, "records", "diff", "-dir", dir)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "no snapshot to compare with")
+
+	st, err := snapshot.NewStore(dir)
+	require.Nil(t, err)
+	require.Nil(t, st.Save(snapshot.New([]expertview.InstallationRecord{
+		{SerialNumber: "296930501", ID: "667769", HardwareProf: "FLX12", SoftwareProf: "FLEX-256-V2",
+			DCF: "SQU-8000-TRKS-000000-131001CL.DCF", Firmware: "8000-01V113R047.BIN"},
+		{SerialNumber: "296930500"},
+	}, time.Now().Add(-24*time.Hour))))
+
+	code, stdout, stderr := runTest(server, "records", "diff", "-dir", dir)
+	require.Equal(t, 0, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"removed", "296930500"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"changed", "296930501", "firmware", "8000-01V113R047.BIN", "8000-01V114R048.BIN"},
+		strings.Fields(lines[2]))
+
+	code, _, stderr = runTest(server, "records", "snapshot", "-dir", dir)
+	require.Equal(t, 0, code, stderr)
+	code, stdout, stderr = runTest(server, "-format", "json", "records", "diff", "-dir", dir)
+	require.Equal(t, 0, code, stderr)
+	var d snapshot.Diff
+	require.Nil(t, json.Unmarshal([]byte(stdout), &d))
+	assert.True(t, d.Empty())
+
+	code, _, stderr = runTest(server, "records", "nope")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown records command "nope"`)
+}
+
+func TestRecordsHistory(t *testing.T) {
+	server := newTestServer(t)
+	dir := t.TempDir()
+	st, err := snapshot.NewStore(dir)
+	require.Nil(t, err)
+	day1 := time.Date(2016, 8, 24, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	unit := expertview.InstallationRecord{
+		SerialNumber: "296930501",
+		DCF:          "SQU-8000-TRKS-000000-131001CL.DCF",
+		Firmware:     "8000-01V113R047.BIN",
+	}
+	require.Nil(t, st.Save(snapshot.New([]expertview.InstallationRecord{unit}, day1)))
+	unit.Firmware = "8000-01V114R048.BIN"
+	require.Nil(t, st.Save(snapshot.New([]expertview.InstallationRecord{unit}, day2)))
+
+	code, stdout, stderr := runTest(server, "records", "history", "-dir", dir, "296930501")
+	require.Equal(t, 0, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"2016-08-25T10:00:00Z", "2016-08-24T10:00:00Z", "changed",
+		"SQU-8000-TRKS-000000-131001CL.DCF", "8000-01V114R048.BIN", "firmware"}, strings.Fields(lines[2]))
+
+	code, stdout, stderr = runTest(server, "-format", "json", "records", "history", "-dir", dir, "-at", "2016-08-24",
+		"296930501")
+	require.Equal(t, 0, code, stderr)
+	var ir expertview.InstallationRecord
+	require.Nil(t, json.Unmarshal([]byte(stdout), &ir))
+	assert.Equal(t, "8000-01V113R047.BIN", ir.Firmware)
+
+	code, _, stderr = runTest(server, "records", "history", "-dir", dir, "-at", "2016-08-23", "296930501")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "not installed at")
+}
@@ -1,10 +1,12 @@
 package expertview
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 )
 
@@ -55,15 +57,15 @@ type FileList struct {
 }
 
 type InstallationRecord struct {
-	SerialNumber string
-	ID           string
-	Telematic    string
-	HardwareProf string
-	SoftwareProf string
-	DCF          string
-	Firmware     string
-	Key          string
-	Username     string
+	SerialNumber string `json:"serialNumber"`
+	ID           string `json:"id"`
+	Telematic    string `json:"telematic"`
+	HardwareProf string `json:"hardwareProf"`
+	SoftwareProf string `json:"softwareProf"`
+	DCF          string `json:"dcf"`
+	Firmware     string `json:"firmware"`
+	Key          string `json:"key"`
+	Username     string `json:"username"`
 }
 
 // ExpertView is a client for the Squarell Expert View webservice.
@@ -71,12 +73,13 @@ type ExpertView struct {
 	version     string
 	cli         *soapCli
 	credentials Credentials
+	retry       RetryPolicy
 }
 
 // NewExpertView returns an *ExpertView, pointing to the given endpoint and using the specified API version and
 // credentials. If no version or endpoint are given, the default values are used instead (DefaultEndpoint and
-// DefaultVersion)
-func NewExpertView(endpoint string, version string, credentials Credentials) (*ExpertView, error) {
+// DefaultVersion). The server certificate is verified unless WithInsecureSkipVerify is given.
+func NewExpertView(endpoint string, version string, credentials Credentials, opts ...Option) (*ExpertView, error) {
 	if endpoint == "" {
 		endpoint = DefaultEndpoint
 	}
@@ -89,58 +92,206 @@ func NewExpertView(endpoint string, version string, credentials Credentials) (*E
 	ev := &ExpertView{
 		version: version,
 		cli: &soapCli{
-			Endpoint:           endpoint,
-			InsecureSkipVerify: true,
+			Endpoint: endpoint,
 		},
 		credentials: credentials,
 	}
+	for _, opt := range opts {
+		opt(ev)
+	}
 	return ev, nil
 }
 
+// CloseIdleConnections closes the keep-alive connections that are not in use. The client stays usable.
+func (ev *ExpertView) CloseIdleConnections() {
+	ev.cli.closeIdleConnections()
+}
+
+// Version returns the API version sent on every request.
+func (ev *ExpertView) Version() string {
+	return ev.version
+}
+
 func (ev *ExpertView) GetFileList() (FileList, error) {
-	doc, err := createGetFileList(ev.credentials, ev.version)
-	if err != nil {
-		return FileList{}, fmt.Errorf("error building xml request: %s", err)
-	}
+	return ev.GetFileListContext(context.Background())
+}
 
-	reqBody := strings.NewReader(doc.String())
-	resp, err := ev.cli.call(reqBody)
-	if err != nil {
-		return FileList{}, err
-	}
+// GetFileListContext is like GetFileList, but the request is bound to ctx.
+func (ev *ExpertView) GetFileListContext(ctx context.Context) (FileList, error) {
+	doc := createGetFileList(ev.credentials, ev.version)
 
-	if len(resp) == 0 {
-		return FileList{}, errors.New("empty response")
-	}
-	return parseGetFileList(resp)
+	var fl FileList
+	err := ev.withRetry(ctx, func() error {
+		reqBody := strings.NewReader(doc.String())
+		resp, err := ev.cli.call(ctx, reqBody)
+		if err != nil {
+			return err
+		}
+
+		if len(resp) == 0 {
+			return errors.New("empty response")
+		}
+		fl, err = parseGetFileList(resp)
+		return err
+	})
+	return fl, err
 }
 
 func (ev *ExpertView) GetFile(filename string) ([]byte, error) {
-	doc, err := createGetFile(ev.credentials, ev.version, filename)
+	return ev.GetFileContext(context.Background(), filename)
+}
+
+// GetFileContext is like GetFile, but the request is bound to ctx.
+func (ev *ExpertView) GetFileContext(ctx context.Context, filename string) ([]byte, error) {
+	doc := createGetFile(ev.credentials, ev.version, filename)
+
+	var f []byte
+	err := ev.withRetry(ctx, func() error {
+		reqBody := strings.NewReader(doc.String())
+		resp, err := ev.cli.call(ctx, reqBody)
+		if err != nil {
+			return err
+		}
+
+		if len(resp) == 0 {
+			return errors.New("empty response")
+		}
+		f, err = parseGetFile(resp)
+		return err
+	})
+	return f, err
+}
+
+// GetFileTo is like GetFileContext, but the file is written to w as it is received instead of being held in
+// memory. It returns the number of bytes written. On error, w may have received part of the file; a failed
+// call is only retried if nothing was written to w yet.
+func (ev *ExpertView) GetFileTo(ctx context.Context, filename string, w io.Writer) (int64, error) {
+	doc := createGetFile(ev.credentials, ev.version, filename)
+
+	var n int64
+	err := ev.withRetry(ctx, func() error {
+		reqBody := strings.NewReader(doc.String())
+		body, err := ev.cli.stream(ctx, reqBody)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+
+		n, err = streamGetFile(body, w)
+		if err != nil && n > 0 {
+			return &noRetryError{err}
+		}
+		return err
+	})
+	return n, err
+}
+
+func (ev *ExpertView) GetInstallationRecords() ([]InstallationRecord, error) {
+	return ev.GetInstallationRecordsContext(context.Background())
+}
+
+// GetInstallationRecordsContext is like GetInstallationRecords, but the request is bound to ctx.
+func (ev *ExpertView) GetInstallationRecordsContext(ctx context.Context) ([]InstallationRecord, error) {
+	doc := createGetInstallRecords(ev.credentials, ev.version)
+
+	var records []InstallationRecord
+	err := ev.withRetry(ctx, func() error {
+		reqBody := strings.NewReader(doc.String())
+		resp, err := ev.cli.call(ctx, reqBody)
+		if err != nil {
+			return err
+		}
+
+		if len(resp) == 0 {
+			return errors.New("empty response")
+		}
+		records, err = parseGetInstallRecords(resp)
+		return err
+	})
+	return records, err
+}
+
+// PostInstallRecords uploads the given installation records to Expert View. It is never retried, since the
+// server may have stored the records even if the call failed.
+func (ev *ExpertView) PostInstallRecords(records []InstallationRecord) error {
+	return ev.PostInstallRecordsContext(context.Background(), records)
+}
+
+// PostInstallRecordsContext is like PostInstallRecords, but the request is bound to ctx.
+func (ev *ExpertView) PostInstallRecordsContext(ctx context.Context, records []InstallationRecord) error {
+	if len(records) == 0 {
+		return errors.New("no installation records to post")
+	}
+
+	payload, err := InstallRecordsPayload(records)
 	if err != nil {
-		return nil, fmt.Errorf("error building xml request: %s", err)
+		return err
 	}
+	doc := createPostInstallRecords(ev.credentials, ev.version, payload)
 
 	reqBody := strings.NewReader(doc.String())
-	resp, err := ev.cli.call(reqBody)
+	resp, err := ev.cli.call(ctx, reqBody)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if len(resp) == 0 {
-		return nil, errors.New("empty response")
+		return errors.New("empty response")
 	}
-	return parseGetFile(resp)
+	return parsePostInstallRecords(resp)
 }
 
-func (ev *ExpertView) GetInstallationRecords() ([]InstallationRecord, error) {
-	doc, err := createGetInstallRecords(ev.credentials, ev.version)
+// GetVersion returns the API version reported by the server.
+func (ev *ExpertView) GetVersion() (Version, error) {
+	return ev.GetVersionContext(context.Background())
+}
+
+// GetVersionContext is like GetVersion, but the request is bound to ctx.
+func (ev *ExpertView) GetVersionContext(ctx context.Context) (Version, error) {
+	doc := createGetVersion()
+
+	var v Version
+	err := ev.withRetry(ctx, func() error {
+		reqBody := strings.NewReader(doc.String())
+		resp, err := ev.cli.call(ctx, reqBody)
+		if err != nil {
+			return err
+		}
+
+		if len(resp) == 0 {
+			return errors.New("empty response")
+		}
+		v, err = parseGetVersion(resp)
+		return err
+	})
+	return v, err
+}
+
+// PutLogBooks uploads the given logbooks to Expert View, returning one result per logbook. Every logbook is
+// validated before anything is sent. Like PostInstallRecords, it is never retried.
+func (ev *ExpertView) PutLogBooks(logBooks []LogBook) ([]LogBookResult, error) {
+	return ev.PutLogBooksContext(context.Background(), logBooks)
+}
+
+// PutLogBooksContext is like PutLogBooks, but the request is bound to ctx.
+func (ev *ExpertView) PutLogBooksContext(ctx context.Context, logBooks []LogBook) ([]LogBookResult, error) {
+	if len(logBooks) == 0 {
+		return nil, errors.New("no logbooks to put")
+	}
+	for i, lb := range logBooks {
+		if err := lb.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid logbook %d: %s", i, err)
+		}
+	}
+
+	payload, err := encodeLogBooks(logBooks)
 	if err != nil {
-		return nil, fmt.Errorf("error building xml request: %s", err)
+		return nil, err
 	}
+	doc := createPutLogBooks(ev.credentials, ev.version, payload)
 
 	reqBody := strings.NewReader(doc.String())
-	resp, err := ev.cli.call(reqBody)
+	resp, err := ev.cli.call(ctx, reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -148,5 +299,5 @@ func (ev *ExpertView) GetInstallationRecords() ([]InstallationRecord, error) {
 	if len(resp) == 0 {
 		return nil, errors.New("empty response")
 	}
-	return parseGetInstallRecords(resp)
+	return parsePutLogBooks(resp)
 }
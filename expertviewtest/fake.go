@@ -0,0 +1,243 @@
+// Package expertviewtest provides test doubles for code using the expertview package.
+package expertviewtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/larixsource/go-expertview"
+)
+
+// Operation names an Expert View operation, to inject errors into a Fake.
+type Operation string
+
+const (
+	GetFileList            Operation = "getFileList"
+	GetFile                Operation = "getFile"
+	GetInstallationRecords Operation = "getInstallRecords"
+	PostInstallRecords     Operation = "postInstallRecords"
+	GetVersion             Operation = "getVersion"
+	PutLogBooks            Operation = "putLogBooks"
+)
+
+// Fake is an in-memory expertview.Client. Its zero value is ready to use: it serves an empty file list, no files
+// and no installation records, and reports expertview.DefaultVersion. A Fake is safe for concurrent use.
+type Fake struct {
+	mu       sync.Mutex
+	fileList expertview.FileList
+	files    map[string][]byte
+	records  []expertview.InstallationRecord
+	version  *expertview.Version
+	logBooks []expertview.LogBook
+	errs     map[Operation]error
+}
+
+var _ expertview.Client = (*Fake)(nil)
+
+// NewFake returns an empty Fake.
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// SetFileList sets the file list returned by GetFileList.
+func (f *Fake) SetFileList(fl expertview.FileList) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fileList = fl
+}
+
+// SetFile sets the contents returned by GetFile for the given file. It does not change the file list.
+func (f *Fake) SetFile(filename string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.files == nil {
+		f.files = make(map[string][]byte)
+	}
+	f.files[filename] = append([]byte(nil), data...)
+}
+
+// AddFile adds a record to the file list and sets its contents.
+func (f *Fake) AddFile(record expertview.Record, data []byte) {
+	f.mu.Lock()
+	f.fileList.Records = append(f.fileList.Records, record)
+	f.mu.Unlock()
+	f.SetFile(record.File, data)
+}
+
+// SetInstallationRecords sets the records returned by GetInstallationRecords.
+func (f *Fake) SetInstallationRecords(records []expertview.InstallationRecord) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records = append([]expertview.InstallationRecord(nil), records...)
+}
+
+// SetVersion sets the version returned by GetVersion.
+func (f *Fake) SetVersion(v expertview.Version) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.version = &v
+}
+
+// SetError makes every call of the given operation fail with err, until it is set to nil. Usually err is one of
+// the expertview sentinel errors, like expertview.ErrAuthentication.
+func (f *Fake) SetError(op Operation, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errs == nil {
+		f.errs = make(map[Operation]error)
+	}
+	f.errs[op] = err
+}
+
+// LogBooks returns the logbooks received by PutLogBooks.
+func (f *Fake) LogBooks() []expertview.LogBook {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]expertview.LogBook(nil), f.logBooks...)
+}
+
+// check returns the error to fail op with, if any. It must be called with f.mu held.
+func (f *Fake) check(ctx context.Context, op Operation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.errs[op]
+}
+
+func (f *Fake) GetFileList() (expertview.FileList, error) {
+	return f.GetFileListContext(context.Background())
+}
+
+func (f *Fake) GetFileListContext(ctx context.Context) (expertview.FileList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check(ctx, GetFileList); err != nil {
+		return expertview.FileList{}, err
+	}
+	return expertview.FileList{
+		DeviceTypes: append([]expertview.DeviceType{}, f.fileList.DeviceTypes...),
+		Records:     append([]expertview.Record{}, f.fileList.Records...),
+	}, nil
+}
+
+func (f *Fake) GetFile(filename string) ([]byte, error) {
+	return f.GetFileContext(context.Background(), filename)
+}
+
+func (f *Fake) GetFileContext(ctx context.Context, filename string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check(ctx, GetFile); err != nil {
+		return nil, err
+	}
+	data, ok := f.files[filename]
+	if !ok {
+		return nil, expertview.ErrNoSuchFile
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (f *Fake) GetFileTo(ctx context.Context, filename string, w io.Writer) (int64, error) {
+	data, err := f.GetFileContext(ctx, filename)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+func (f *Fake) GetInstallationRecords() ([]expertview.InstallationRecord, error) {
+	return f.GetInstallationRecordsContext(context.Background())
+}
+
+func (f *Fake) GetInstallationRecordsContext(ctx context.Context) ([]expertview.InstallationRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check(ctx, GetInstallationRecords); err != nil {
+		return nil, err
+	}
+	return append([]expertview.InstallationRecord{}, f.records...), nil
+}
+
+func (f *Fake) PostInstallRecords(records []expertview.InstallationRecord) error {
+	return f.PostInstallRecordsContext(context.Background(), records)
+}
+
+// PostInstallRecordsContext adds the records to the ones returned by GetInstallationRecords, replacing those with
+// the same serial number.
+func (f *Fake) PostInstallRecordsContext(ctx context.Context, records []expertview.InstallationRecord) error {
+	if len(records) == 0 {
+		return errors.New("no installation records to post")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check(ctx, PostInstallRecords); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		replaced := false
+		for i := range f.records {
+			if f.records[i].SerialNumber == rec.SerialNumber {
+				f.records[i] = rec
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			f.records = append(f.records, rec)
+		}
+	}
+	return nil
+}
+
+func (f *Fake) GetVersion() (expertview.Version, error) {
+	return f.GetVersionContext(context.Background())
+}
+
+func (f *Fake) GetVersionContext(ctx context.Context) (expertview.Version, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check(ctx, GetVersion); err != nil {
+		return expertview.Version{}, err
+	}
+	if f.version == nil {
+		return expertview.ParseVersion(expertview.DefaultVersion)
+	}
+	return *f.version, nil
+}
+
+func (f *Fake) PutLogBooks(logBooks []expertview.LogBook) ([]expertview.LogBookResult, error) {
+	return f.PutLogBooksContext(context.Background(), logBooks)
+}
+
+// PutLogBooksContext validates and keeps the logbooks (see LogBooks), accepting all their entries.
+func (f *Fake) PutLogBooksContext(ctx context.Context, logBooks []expertview.LogBook) ([]expertview.LogBookResult, error) {
+	if len(logBooks) == 0 {
+		return nil, errors.New("no logbooks to put")
+	}
+	for i, lb := range logBooks {
+		if err := lb.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid logbook %d: %s", i, err)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check(ctx, PutLogBooks); err != nil {
+		return nil, err
+	}
+	results := make([]expertview.LogBookResult, 0, len(logBooks))
+	for _, lb := range logBooks {
+		f.logBooks = append(f.logBooks, lb)
+		results = append(results, expertview.LogBookResult{
+			SerialNumber: lb.SerialNumber,
+			Accepted:     len(lb.Entries),
+			Message:      "OK",
+		})
+	}
+	return results, nil
+}
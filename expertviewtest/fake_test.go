@@ -0,0 +1,84 @@
+package expertviewtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	var c expertview.Client
+	fake := NewFake()
+	c = fake
+
+	fake.AddFile(expertview.Record{
+		Kind: expertview.DCFKind,
+		Name: "INTA1-FLX12-MBA+VDO-RS-120224CL.DCF",
+		File: "D9984527582012022715184747.dcf",
+	}, []byte("hello"))
+	fake.SetInstallationRecords([]expertview.InstallationRecord{
+		{SerialNumber: "296930501", Firmware: "8000-01V114R048.BIN"},
+	})
+
+	fl, err := c.GetFileList()
+	require.Nil(t, err)
+	assert.Len(t, fl.Records, 1)
+	assert.Equal(t, "D9984527582012022715184747.dcf", fl.Records[0].File)
+
+	f, err := c.GetFile("D9984527582012022715184747.dcf")
+	require.Nil(t, err)
+	assert.Equal(t, []byte("hello"), f)
+
+	var buf bytes.Buffer
+	n, err := c.GetFileTo(context.Background(), "D9984527582012022715184747.dcf", &buf)
+	require.Nil(t, err)
+	assert.Equal(t, int64(5), n)
+	assert.Equal(t, "hello", buf.String())
+
+	_, err = c.GetFile("D0000000000000000000000000.dcf")
+	assert.Equal(t, expertview.ErrNoSuchFile, err)
+
+	err = c.PostInstallRecords([]expertview.InstallationRecord{
+		{SerialNumber: "296930501", Firmware: "8000-01V114R050.BIN"},
+		{SerialNumber: "296930502", Firmware: "8000-01V114R048.BIN"},
+	})
+	require.Nil(t, err)
+	records, err := c.GetInstallationRecords()
+	require.Nil(t, err)
+	assert.Equal(t, []expertview.InstallationRecord{
+		{SerialNumber: "296930501", Firmware: "8000-01V114R050.BIN"},
+		{SerialNumber: "296930502", Firmware: "8000-01V114R048.BIN"},
+	}, records)
+
+	v, err := c.GetVersion()
+	require.Nil(t, err)
+	assert.Equal(t, expertview.Version{Major: 2, Minor: 5}, v)
+}
+
+func TestFake_SetError(t *testing.T) {
+	fake := NewFake()
+	fake.SetError(GetInstallationRecords, expertview.ErrAuthentication)
+
+	_, err := fake.GetInstallationRecords()
+	assert.True(t, errors.Is(err, expertview.ErrAuthentication))
+	_, err = fake.GetFileList()
+	assert.Nil(t, err)
+
+	fake.SetError(GetInstallationRecords, nil)
+	_, err = fake.GetInstallationRecords()
+	assert.Nil(t, err)
+}
+
+func TestFake_Canceled(t *testing.T) {
+	fake := NewFake()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := fake.GetFileListContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,411 @@
+package expertviewtest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+)
+
+const (
+	soapEnvelopeNS = "http://schemas.xmlsoap.org/soap/envelope/"
+	expertViewNS   = "http://webservice.expertview.squarell.com/"
+
+	payloadHeader   = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`
+	faultTimeLayout = "2006/01/02 15:04:05.000"
+	logBookLayout   = "2006/01/02 15:04:05"
+)
+
+// Server is a fake Expert View webservice speaking SOAP over HTTPS, for end-to-end tests of an
+// *expertview.ExpertView. It serves the data seeded into its Fake, as base64 encoded <return> payloads, and
+// answers with SOAP faults like the real service when the login fails, a file does not exist or an error has been
+// injected with Fake.SetError.
+type Server struct {
+	*httptest.Server
+
+	// Fake holds the data served, and the records and logbooks received.
+	Fake *Fake
+
+	credentials expertview.Credentials
+}
+
+// NewServer starts a Server accepting the given credentials. The caller should call Close when finished.
+func NewServer(credentials expertview.Credentials) *Server {
+	s := &Server{
+		Fake:        NewFake(),
+		credentials: credentials,
+	}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveSOAP))
+	return s
+}
+
+// NewClient returns an *expertview.ExpertView calling s with its credentials, trusting its certificate.
+func (s *Server) NewClient(opts ...expertview.Option) (*expertview.ExpertView, error) {
+	return s.NewClientWithCredentials(s.credentials, opts...)
+}
+
+// NewClientWithCredentials is like NewClient, but the client uses the given credentials.
+func (s *Server) NewClientWithCredentials(credentials expertview.Credentials, opts ...expertview.Option) (*expertview.ExpertView, error) {
+	opts = append([]expertview.Option{expertview.WithHTTPClient(s.Client())}, opts...)
+	return expertview.NewExpertView(s.URL, expertview.DefaultVersion, credentials, opts...)
+}
+
+type requestEnvelope struct {
+	XMLName xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"`
+
+	Body struct {
+		Operation requestOperation `xml:",any"`
+	} `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body"`
+}
+
+type requestOperation struct {
+	XMLName xml.Name
+
+	Login    string `xml:"login"`
+	Password string `xml:"password"`
+	Version  string `xml:"version"`
+	Filename string `xml:"filename"`
+	Records  string `xml:"records"`
+	LogBooks string `xml:"logbooks"`
+}
+
+func (s *Server) serveSOAP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var env requestEnvelope
+	err := xml.NewDecoder(r.Body).Decode(&env)
+	if err != nil {
+		writeFault(rw, "", fmt.Sprintf("Couldn't create SOAP message due to exception: %s", err))
+		return
+	}
+
+	op := env.Body.Operation
+	if op.XMLName.Space != expertViewNS {
+		writeFault(rw, "", fmt.Sprintf("Cannot find dispatch method for {%s}%s", op.XMLName.Space, op.XMLName.Local))
+		return
+	}
+	if op.XMLName.Local != string(GetVersion) && !s.authenticate(op) {
+		writeFault(rw, expertview.AuthenticationException, "Login failed")
+		return
+	}
+
+	ctx := r.Context()
+	var payload []byte
+	switch Operation(op.XMLName.Local) {
+	case GetFileList:
+		var fl expertview.FileList
+		fl, err = s.Fake.GetFileListContext(ctx)
+		if err == nil {
+			payload, err = encodeFileList(fl)
+		}
+	case GetFile:
+		payload, err = s.Fake.GetFileContext(ctx, op.Filename)
+	case GetInstallationRecords:
+		var records []expertview.InstallationRecord
+		records, err = s.Fake.GetInstallationRecordsContext(ctx)
+		if err == nil {
+			payload, err = encodeInstallRecords(records)
+		}
+	case PostInstallRecords:
+		var records []expertview.InstallationRecord
+		records, err = decodeInstallRecords(op.Records)
+		if err == nil {
+			err = s.Fake.PostInstallRecordsContext(ctx, records)
+		}
+		if err == nil {
+			writeResponse(rw, op.XMLName.Local, nil)
+			return
+		}
+	case GetVersion:
+		var v expertview.Version
+		v, err = s.Fake.GetVersionContext(ctx)
+		if err == nil {
+			writeResponse(rw, op.XMLName.Local, []byte(v.String()))
+			return
+		}
+	case PutLogBooks:
+		var logBooks []expertview.LogBook
+		logBooks, err = decodeLogBooks(op.LogBooks)
+		if err == nil {
+			var results []expertview.LogBookResult
+			results, err = s.Fake.PutLogBooksContext(ctx, logBooks)
+			if err == nil {
+				payload, err = encodeLogBookResults(results)
+			}
+		}
+	default:
+		writeFault(rw, "", fmt.Sprintf("Cannot find dispatch method for {%s}%s", op.XMLName.Space, op.XMLName.Local))
+		return
+	}
+
+	if err != nil {
+		writeErrorFault(rw, op, err)
+		return
+	}
+	writeResponse(rw, op.XMLName.Local, []byte(base64.StdEncoding.EncodeToString(payload)))
+}
+
+func (s *Server) authenticate(op requestOperation) bool {
+	return op.Login == s.credentials.Login && op.Password == s.credentials.PasswordMD5Sum()
+}
+
+func writeResponse(rw http.ResponseWriter, operation string, ret []byte) {
+	var buf bytes.Buffer
+	buf.WriteString(`<S:Envelope xmlns:S="` + soapEnvelopeNS + `"><S:Body>`)
+	buf.WriteString(`<ns2:` + operation + `Response xmlns:ns2="` + expertViewNS + `">`)
+	if ret != nil {
+		buf.WriteString("<return>")
+		xml.EscapeText(&buf, ret)
+		buf.WriteString("</return>")
+	}
+	buf.WriteString(`</ns2:` + operation + `Response>`)
+	buf.WriteString(`</S:Body></S:Envelope>`)
+
+	rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	rw.Write(buf.Bytes())
+}
+
+func writeErrorFault(rw http.ResponseWriter, op requestOperation, err error) {
+	switch {
+	case errors.Is(err, expertview.ErrAuthentication):
+		writeFault(rw, expertview.AuthenticationException, "Login failed")
+	case errors.Is(err, expertview.ErrNoSuchFile):
+		writeFault(rw, expertview.NoSuchFileException, "No such file: "+op.Filename)
+	case errors.Is(err, expertview.ErrFirmwareNotSelectable):
+		writeFault(rw, expertview.FirmwareNotSelectableException, "FirmwareNotSelectable exception")
+	default:
+		writeFault(rw, expertview.UnexpectedException, err.Error())
+	}
+}
+
+// writeFault writes a fault with the given exception in its detail, or no detail if exception is empty. The
+// message is prefixed with the server time, as Expert View does.
+func writeFault(rw http.ResponseWriter, exception string, message string) {
+	message = "[" + time.Now().UTC().Format(faultTimeLayout) + "] " + message
+
+	var buf bytes.Buffer
+	buf.WriteString(`<S:Envelope xmlns:S="` + soapEnvelopeNS + `"><S:Body>`)
+	buf.WriteString(`<S:Fault xmlns:ns4="http://www.w3.org/2003/05/soap-envelope">`)
+	buf.WriteString(`<faultcode>S:Server</faultcode><faultstring>`)
+	xml.EscapeText(&buf, []byte(message))
+	buf.WriteString(`</faultstring>`)
+	if exception != "" {
+		buf.WriteString(`<detail><ns2:` + exception + ` xmlns:ns2="` + expertViewNS + `"><message>`)
+		xml.EscapeText(&buf, []byte(message))
+		buf.WriteString(`</message></ns2:` + exception + `></detail>`)
+	}
+	buf.WriteString(`</S:Fault></S:Body></S:Envelope>`)
+
+	rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	rw.WriteHeader(http.StatusInternalServerError)
+	rw.Write(buf.Bytes())
+}
+
+type fileListXml struct {
+	XMLName     xml.Name        `xml:"RESPONSE"`
+	DeviceTypes []deviceTypeXml `xml:"DEVICETYPES>DEVICETYPE"`
+	Files       []recordXml     `xml:"FILES>RECORD"`
+}
+
+type deviceTypeXml struct {
+	Description   string `xml:"DESCRIPTION,attr"`
+	ProductNumber string `xml:"PRODUCTNUMBER,attr"`
+}
+
+type recordXml struct {
+	Kind string `xml:"KIND,attr"`
+	Name string `xml:"NAME"`
+	File string `xml:"FILE"`
+}
+
+func encodeFileList(fl expertview.FileList) ([]byte, error) {
+	var flx fileListXml
+	for _, dt := range fl.DeviceTypes {
+		flx.DeviceTypes = append(flx.DeviceTypes, deviceTypeXml{
+			Description:   dt.Description,
+			ProductNumber: dt.ProductNumber,
+		})
+	}
+	for _, rec := range fl.Records {
+		flx.Files = append(flx.Files, recordXml{
+			Kind: string(rec.Kind),
+			Name: rec.Name,
+			File: rec.File,
+		})
+	}
+	return marshalPayload(flx)
+}
+
+type installRecordsXml struct {
+	XMLName xml.Name           `xml:"INSTALLATIONS"`
+	Records []installRecordXml `xml:"RECORD"`
+}
+
+type installRecordXml struct {
+	SN           string `xml:"SN,attr"`
+	ID           string `xml:"ID"`
+	Telematic    string `xml:"TELEMATIC"`
+	HardwareProf string `xml:"HARDWAREPROF"`
+	SoftwareProf string `xml:"SOFTWAREPROF"`
+	DCF          string `xml:"DCF"`
+	Firmware     string `xml:"FIRMWARE"`
+	Key          string `xml:"KEY"`
+	Username     string `xml:"USERNAME"`
+}
+
+func encodeInstallRecords(records []expertview.InstallationRecord) ([]byte, error) {
+	var irx installRecordsXml
+	for _, rec := range records {
+		irx.Records = append(irx.Records, installRecordXml{
+			SN:           rec.SerialNumber,
+			ID:           rec.ID,
+			Telematic:    rec.Telematic,
+			HardwareProf: rec.HardwareProf,
+			SoftwareProf: rec.SoftwareProf,
+			DCF:          rec.DCF,
+			Firmware:     rec.Firmware,
+			Key:          rec.Key,
+			Username:     rec.Username,
+		})
+	}
+	return marshalPayload(irx)
+}
+
+func decodeInstallRecords(b64 string) ([]expertview.InstallationRecord, error) {
+	var irx installRecordsXml
+	err := unmarshalPayload(b64, &irx)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]expertview.InstallationRecord, 0, len(irx.Records))
+	for _, rec := range irx.Records {
+		records = append(records, expertview.InstallationRecord{
+			SerialNumber: rec.SN,
+			ID:           rec.ID,
+			Telematic:    rec.Telematic,
+			HardwareProf: rec.HardwareProf,
+			SoftwareProf: rec.SoftwareProf,
+			DCF:          rec.DCF,
+			Firmware:     rec.Firmware,
+			Key:          rec.Key,
+			Username:     rec.Username,
+		})
+	}
+	return records, nil
+}
+
+type logBooksXml struct {
+	XMLName  xml.Name     `xml:"LOGBOOKS"`
+	LogBooks []logBookXml `xml:"LOGBOOK"`
+}
+
+type logBookXml struct {
+	SN      string            `xml:"SN,attr"`
+	Driver  string            `xml:"DRIVER"`
+	Vehicle string            `xml:"VEHICLE"`
+	Entries []logBookEntryXml `xml:"ENTRIES>ENTRY"`
+}
+
+type logBookEntryXml struct {
+	Activity      string `xml:"ACTIVITY,attr"`
+	Start         string `xml:"START"`
+	End           string `xml:"END"`
+	StartOdometer string `xml:"STARTODOMETER"`
+	EndOdometer   string `xml:"ENDODOMETER"`
+	Description   string `xml:"DESCRIPTION"`
+}
+
+func decodeLogBooks(b64 string) ([]expertview.LogBook, error) {
+	var lbx logBooksXml
+	err := unmarshalPayload(b64, &lbx)
+	if err != nil {
+		return nil, err
+	}
+	logBooks := make([]expertview.LogBook, 0, len(lbx.LogBooks))
+	for _, lb := range lbx.LogBooks {
+		entries := make([]expertview.LogBookEntry, 0, len(lb.Entries))
+		for _, e := range lb.Entries {
+			entry := expertview.LogBookEntry{
+				Activity:    expertview.LogBookActivity(e.Activity),
+				Description: e.Description,
+			}
+			if entry.Start, err = time.Parse(logBookLayout, e.Start); err != nil {
+				return nil, err
+			}
+			if entry.End, err = time.Parse(logBookLayout, e.End); err != nil {
+				return nil, err
+			}
+			if entry.StartOdometer, err = parseOdometer(e.StartOdometer); err != nil {
+				return nil, err
+			}
+			if entry.EndOdometer, err = parseOdometer(e.EndOdometer); err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+		logBooks = append(logBooks, expertview.LogBook{
+			SerialNumber: lb.SN,
+			Driver:       lb.Driver,
+			Vehicle:      lb.Vehicle,
+			Entries:      entries,
+		})
+	}
+	return logBooks, nil
+}
+
+func parseOdometer(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+type logBookResultsXml struct {
+	XMLName xml.Name           `xml:"RESULTS"`
+	Results []logBookResultXml `xml:"RESULT"`
+}
+
+type logBookResultXml struct {
+	SN       string `xml:"SN,attr"`
+	Accepted int    `xml:"ACCEPTED"`
+	Rejected int    `xml:"REJECTED"`
+	Message  string `xml:"MESSAGE"`
+}
+
+func encodeLogBookResults(results []expertview.LogBookResult) ([]byte, error) {
+	var lrx logBookResultsXml
+	for _, res := range results {
+		lrx.Results = append(lrx.Results, logBookResultXml{
+			SN:       res.SerialNumber,
+			Accepted: res.Accepted,
+			Rejected: res.Rejected,
+			Message:  res.Message,
+		})
+	}
+	return marshalPayload(lrx)
+}
+
+func marshalPayload(v interface{}) ([]byte, error) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(payloadHeader), b...), nil
+}
+
+func unmarshalPayload(b64 string, v interface{}) error {
+	b, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(b, v)
+}
@@ -0,0 +1,139 @@
+package expertviewtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCredentials = expertview.Credentials{
+	Login:    "demo",
+	Password: "demo",
+}
+
+func TestServer(t *testing.T) {
+	server := NewServer(testCredentials)
+	defer server.Close()
+	server.Fake.SetFileList(expertview.FileList{
+		DeviceTypes: []expertview.DeviceType{
+			{Description: "Normal (Solid/Flex)", ProductNumber: "8000-1"},
+		},
+	})
+	server.Fake.AddFile(expertview.Record{
+		Kind: expertview.DCFKind,
+		Name: "INTA1-FLX12-MBA+VDO-RS-120224CL.DCF",
+		File: "D9984527582012022715184747.dcf",
+	}, []byte("hello"))
+	server.Fake.SetVersion(expertview.Version{Major: 2, Minor: 6})
+
+	ev, err := server.NewClient()
+	require.Nil(t, err)
+
+	fl, err := ev.GetFileList()
+	require.Nil(t, err)
+	assert.Equal(t, []expertview.DeviceType{{Description: "Normal (Solid/Flex)", ProductNumber: "8000-1"}}, fl.DeviceTypes)
+	assert.Equal(t, []expertview.Record{{
+		Kind: expertview.DCFKind,
+		Name: "INTA1-FLX12-MBA+VDO-RS-120224CL.DCF",
+		File: "D9984527582012022715184747.dcf",
+	}}, fl.Records)
+
+	f, err := ev.GetFile("D9984527582012022715184747.dcf")
+	require.Nil(t, err)
+	assert.Equal(t, []byte("hello"), f)
+
+	var buf bytes.Buffer
+	_, err = ev.GetFileTo(context.Background(), "D9984527582012022715184747.dcf", &buf)
+	require.Nil(t, err)
+	assert.Equal(t, "hello", buf.String())
+
+	records := []expertview.InstallationRecord{{
+		SerialNumber: "296930501",
+		ID:           "667769",
+		Telematic:    "Larix Ltda",
+		HardwareProf: "FLX12",
+		SoftwareProf: "FLEX-256-V2",
+		DCF:          "SQU-8000-TRKS-000000-131001CL.DCF",
+		Firmware:     "8000-01V114R048.BIN",
+		Key:          "293230583 FLX12 FLEX-256-V2 hjashj dshj dahy",
+		Username:     "asdf",
+	}}
+	err = ev.PostInstallRecords(records)
+	require.Nil(t, err)
+	got, err := ev.GetInstallationRecords()
+	require.Nil(t, err)
+	assert.Equal(t, records, got)
+
+	v, err := ev.GetVersion()
+	require.Nil(t, err)
+	assert.Equal(t, expertview.Version{Major: 2, Minor: 6}, v)
+
+	start := time.Date(2016, 8, 25, 8, 0, 0, 0, time.UTC)
+	logBooks := []expertview.LogBook{{
+		SerialNumber: "296930501",
+		Driver:       "J. Perez",
+		Entries: []expertview.LogBookEntry{{
+			Start:         start,
+			End:           start.Add(time.Hour),
+			Activity:      expertview.DrivingActivity,
+			StartOdometer: 100,
+			EndOdometer:   180.5,
+		}},
+	}}
+	results, err := ev.PutLogBooks(logBooks)
+	require.Nil(t, err)
+	assert.Equal(t, []expertview.LogBookResult{{SerialNumber: "296930501", Accepted: 1, Message: "OK"}}, results)
+	assert.Equal(t, logBooks, server.Fake.LogBooks())
+}
+
+func TestServer_EmptyFile(t *testing.T) {
+	server := NewServer(testCredentials)
+	defer server.Close()
+	server.Fake.AddFile(expertview.Record{Kind: expertview.DCFKind, Name: "EMPTY.DCF", File: "empty.dcf"}, nil)
+
+	req := `<S:Envelope xmlns:S="http://schemas.xmlsoap.org/soap/envelope/" xmlns:sq="` + expertViewNS + `"><S:Body>` +
+		`<sq:getFile><login>demo</login><password>` + testCredentials.PasswordMD5Sum() + `</password>` +
+		`<version>2.5.0</version><filename>empty.dcf</filename></sq:getFile></S:Body></S:Envelope>`
+	resp, err := server.Client().Post(server.URL, "text/xml; charset=utf-8", strings.NewReader(req))
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	assert.Contains(t, string(b), "<ns2:getFileResponse")
+	assert.Contains(t, string(b), "<return></return>")
+}
+
+func TestServer_Faults(t *testing.T) {
+	server := NewServer(testCredentials)
+	defer server.Close()
+
+	ev, err := server.NewClientWithCredentials(expertview.Credentials{Login: "demo", Password: "wrong"})
+	require.Nil(t, err)
+	_, err = ev.GetFileList()
+	assert.True(t, errors.Is(err, expertview.ErrAuthentication), "unexpected error: %v", err)
+
+	ev, err = server.NewClient()
+	require.Nil(t, err)
+	_, err = ev.GetFile("D0000000000000000000000000.dcf")
+	assert.True(t, errors.Is(err, expertview.ErrNoSuchFile), "unexpected error: %v", err)
+	var fe *expertview.FaultError
+	require.True(t, errors.As(err, &fe))
+	assert.Equal(t, expertview.NoSuchFileException, fe.Exception)
+	assert.False(t, fe.Time.IsZero())
+
+	server.Fake.SetError(GetFile, expertview.ErrFirmwareNotSelectable)
+	_, err = ev.GetFile("D0000000000000000000000000.dcf")
+	assert.True(t, errors.Is(err, expertview.ErrFirmwareNotSelectable), "unexpected error: %v", err)
+
+	server.Fake.SetError(GetInstallationRecords, expertview.ErrUnexpected)
+	_, err = ev.GetInstallationRecords()
+	assert.True(t, errors.Is(err, expertview.ErrUnexpected), "unexpected error: %v", err)
+}
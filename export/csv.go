@@ -0,0 +1,186 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/larixsource/go-expertview"
+)
+
+// Column is a CSV column: a field, with the name used in the header row.
+type Column struct {
+	Field Field
+	// Header is the name of the column in the header row. The field name is used if it is empty.
+	Header string
+}
+
+func (c Column) header() string {
+	if c.Header != "" {
+		return c.Header
+	}
+	return string(c.Field)
+}
+
+// CSVOptions tune the CSV writer and reader.
+type CSVOptions struct {
+	// Columns are the columns written, in order. Every field is written, named after its JSON tag, if it is
+	// empty. The reader only takes the fields of Columns, matching their header case-insensitively.
+	Columns []Column
+	// Comma is the field delimiter, ',' if zero.
+	Comma rune
+	// NoHeader drops the header row. The reader then expects the columns in the order of Columns.
+	NoHeader bool
+}
+
+func (o CSVOptions) columns() []Column {
+	if len(o.Columns) > 0 {
+		return o.Columns
+	}
+	columns := make([]Column, len(Fields))
+	for i, f := range Fields {
+		columns[i] = Column{Field: f}
+	}
+	return columns
+}
+
+// CSVWriter writes installation records as CSV rows.
+type CSVWriter struct {
+	w       *csv.Writer
+	columns []Column
+	header  bool
+	row     []string
+}
+
+// NewCSVWriter returns a CSVWriter to w. The header row is written with the first record, or on Close if there
+// are no records.
+func NewCSVWriter(w io.Writer, opts CSVOptions) *CSVWriter {
+	cw := &CSVWriter{
+		w:       csv.NewWriter(w),
+		columns: opts.columns(),
+		header:  !opts.NoHeader,
+	}
+	if opts.Comma != 0 {
+		cw.w.Comma = opts.Comma
+	}
+	cw.row = make([]string, len(cw.columns))
+	return cw
+}
+
+func (cw *CSVWriter) writeHeader() error {
+	if !cw.header {
+		return nil
+	}
+	cw.header = false
+	for i, c := range cw.columns {
+		cw.row[i] = c.header()
+	}
+	return cw.w.Write(cw.row)
+}
+
+// Write writes ir as a row.
+func (cw *CSVWriter) Write(ir expertview.InstallationRecord) error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	for i, c := range cw.columns {
+		cw.row[i] = c.Field.Value(&ir)
+	}
+	return cw.w.Write(cw.row)
+}
+
+// Close flushes the rows written.
+func (cw *CSVWriter) Close() error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// CSVReader reads installation records from CSV rows.
+type CSVReader struct {
+	r       *csv.Reader
+	columns []Column
+	header  bool
+	// fields maps every position of a row to a field, or to "" for ignored columns
+	fields []Field
+	line   int
+}
+
+// NewCSVReader returns a CSVReader from r. Unless opts.NoHeader is set, the first row is taken as the header, and
+// its unknown columns are ignored.
+func NewCSVReader(r io.Reader, opts CSVOptions) *CSVReader {
+	cr := &CSVReader{
+		r:       csv.NewReader(r),
+		columns: opts.columns(),
+		header:  !opts.NoHeader,
+	}
+	if opts.Comma != 0 {
+		cr.r.Comma = opts.Comma
+	}
+	cr.r.FieldsPerRecord = -1
+	if opts.NoHeader {
+		for _, c := range cr.columns {
+			cr.fields = append(cr.fields, c.Field)
+		}
+	}
+	return cr
+}
+
+func (cr *CSVReader) readHeader() error {
+	cr.header = false
+	row, err := cr.r.Read()
+	if err == io.EOF {
+		return io.EOF
+	}
+	if err != nil {
+		return err
+	}
+	known := false
+	cr.fields = make([]Field, len(row))
+	for i, name := range row {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.TrimSpace(name)
+		for _, c := range cr.columns {
+			if strings.EqualFold(name, c.header()) {
+				cr.fields[i] = c.Field
+				known = true
+				break
+			}
+		}
+	}
+	if !known {
+		return errors.New("no known column in the CSV header")
+	}
+	return nil
+}
+
+// Read reads the next row. Missing trailing columns are left empty.
+func (cr *CSVReader) Read() (expertview.InstallationRecord, error) {
+	var ir expertview.InstallationRecord
+	if cr.header {
+		if err := cr.readHeader(); err != nil {
+			return ir, err
+		}
+	}
+	row, err := cr.r.Read()
+	if err != nil {
+		return ir, err
+	}
+	cr.line, _ = cr.r.FieldPos(0)
+	for i, v := range row {
+		if i < len(cr.fields) && cr.fields[i] != "" {
+			cr.fields[i].Set(&ir, strings.TrimSpace(v))
+		}
+	}
+	return ir, nil
+}
+
+// Line returns the line, starting at 1, of the last row read.
+func (cr *CSVReader) Line() int {
+	return cr.line
+}
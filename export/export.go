@@ -0,0 +1,142 @@
+// Package export writes installation records to CSV, JSON and NDJSON, and reads them back. Writers and readers
+// stream, one record at a time.
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/larixsource/go-expertview"
+)
+
+// Field is a field of expertview.InstallationRecord, named like its JSON tag.
+type Field string
+
+const (
+	SerialNumber Field = "serialNumber"
+	ID           Field = "id"
+	Telematic    Field = "telematic"
+	HardwareProf Field = "hardwareProf"
+	SoftwareProf Field = "softwareProf"
+	DCF          Field = "dcf"
+	Firmware     Field = "firmware"
+	Key          Field = "key"
+	Username     Field = "username"
+)
+
+// Fields are all the fields of an installation record, in declaration order.
+var Fields = []Field{SerialNumber, ID, Telematic, HardwareProf, SoftwareProf, DCF, Firmware, Key, Username}
+
+// Value returns the value of the field in ir.
+func (f Field) Value(ir *expertview.InstallationRecord) string {
+	if p := f.ptr(ir); p != nil {
+		return *p
+	}
+	return ""
+}
+
+// Set sets the field of ir to v. It fails if f is not a known field.
+func (f Field) Set(ir *expertview.InstallationRecord, v string) error {
+	p := f.ptr(ir)
+	if p == nil {
+		return fmt.Errorf("unknown field %q", string(f))
+	}
+	*p = v
+	return nil
+}
+
+func (f Field) ptr(ir *expertview.InstallationRecord) *string {
+	switch f {
+	case SerialNumber:
+		return &ir.SerialNumber
+	case ID:
+		return &ir.ID
+	case Telematic:
+		return &ir.Telematic
+	case HardwareProf:
+		return &ir.HardwareProf
+	case SoftwareProf:
+		return &ir.SoftwareProf
+	case DCF:
+		return &ir.DCF
+	case Firmware:
+		return &ir.Firmware
+	case Key:
+		return &ir.Key
+	case Username:
+		return &ir.Username
+	}
+	return nil
+}
+
+// Format is an export format.
+type Format string
+
+const (
+	CSV    Format = "csv"
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+)
+
+// Writer writes installation records one at a time. Close must be called once all the records are written; it
+// completes the output but does not close the underlying io.Writer.
+type Writer interface {
+	Write(ir expertview.InstallationRecord) error
+	Close() error
+}
+
+// Reader reads installation records one at a time. Read returns io.EOF once there are no more records.
+type Reader interface {
+	Read() (expertview.InstallationRecord, error)
+}
+
+// NewWriter returns a Writer of the given format to w, with the default options.
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case CSV:
+		return NewCSVWriter(w, CSVOptions{}), nil
+	case JSON:
+		return NewJSONWriter(w), nil
+	case NDJSON:
+		return NewNDJSONWriter(w), nil
+	}
+	return nil, fmt.Errorf("unknown format %q", string(format))
+}
+
+// NewReader returns a Reader of the given format from r, with the default options.
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case CSV:
+		return NewCSVReader(r, CSVOptions{}), nil
+	case JSON:
+		return NewJSONReader(r), nil
+	case NDJSON:
+		return NewNDJSONReader(r), nil
+	}
+	return nil, fmt.Errorf("unknown format %q", string(format))
+}
+
+// WriteAll writes records to w and closes it.
+func WriteAll(w Writer, records []expertview.InstallationRecord) error {
+	for _, ir := range records {
+		if err := w.Write(ir); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// ReadAll reads the records of r until io.EOF.
+func ReadAll(r Reader) ([]expertview.InstallationRecord, error) {
+	var records []expertview.InstallationRecord
+	for {
+		ir, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, ir)
+	}
+}
@@ -0,0 +1,148 @@
+package export
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRecords = []expertview.InstallationRecord{
+	{
+		SerialNumber: "296930501",
+		ID:           "667769",
+		Telematic:    "Larix Ltda",
+		HardwareProf: "FLX12",
+		SoftwareProf: "FLEX-256-V2",
+		DCF:          "SQU-8000-TRKS-000000-131001CL.DCF",
+		Firmware:     "8000-01V114R048.BIN",
+		Key:          "293230583 FLX12 FLEX-256-V2 hjashj dshj dahy",
+		Username:     "asdf",
+	},
+	{
+		SerialNumber: "296930502",
+		Telematic:    "Larix, \"Ltda\"",
+		DCF:          "SQU-8000-TRKS-000000-131002CL.DCF",
+	},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{CSV, JSON, NDJSON} {
+		var buf bytes.Buffer
+		w, err := NewWriter(format, &buf)
+		require.Nil(t, err)
+		require.Nil(t, WriteAll(w, testRecords))
+
+		r, err := NewReader(format, &buf)
+		require.Nil(t, err)
+		records, err := ReadAll(r)
+		require.Nil(t, err, format)
+		assert.Equal(t, testRecords, records, format)
+	}
+
+	_, err := NewWriter("xml", &bytes.Buffer{})
+	assert.EqualError(t, err, `unknown format "xml"`)
+}
+
+func TestEmpty(t *testing.T) {
+	for format, want := range map[Format]string{
+		CSV:    "serialNumber,id,telematic,hardwareProf,softwareProf,dcf,firmware,key,username\n",
+		JSON:   "[]\n",
+		NDJSON: "",
+	} {
+		var buf bytes.Buffer
+		w, err := NewWriter(format, &buf)
+		require.Nil(t, err)
+		require.Nil(t, WriteAll(w, nil))
+		assert.Equal(t, want, buf.String(), format)
+
+		r, err := NewReader(format, &buf)
+		require.Nil(t, err)
+		records, err := ReadAll(r)
+		assert.Nil(t, err, format)
+		assert.Empty(t, records, format)
+	}
+}
+
+func TestCSVWriter_Columns(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf, CSVOptions{
+		Columns: []Column{
+			{Field: SerialNumber, Header: "Serial"},
+			{Field: Firmware},
+		},
+		Comma: ';',
+	})
+	require.Nil(t, WriteAll(w, testRecords))
+	assert.Equal(t, "Serial;firmware\n296930501;8000-01V114R048.BIN\n296930502;\n", buf.String())
+}
+
+func TestCSVReader(t *testing.T) {
+	in := "\ufeff Serial ,Notes,DCF\n" +
+		"296930501,fitted by Juan,SQU-8000-TRKS-000000-131001CL.DCF\n" +
+		"296930502\n"
+	r := NewCSVReader(strings.NewReader(in), CSVOptions{
+		Columns: []Column{
+			{Field: SerialNumber, Header: "serial"},
+			{Field: DCF},
+		},
+	})
+
+	ir, err := r.Read()
+	require.Nil(t, err)
+	assert.Equal(t, expertview.InstallationRecord{
+		SerialNumber: "296930501",
+		DCF:          "SQU-8000-TRKS-000000-131001CL.DCF",
+	}, ir)
+	assert.Equal(t, 2, r.Line())
+
+	ir, err = r.Read()
+	require.Nil(t, err)
+	assert.Equal(t, expertview.InstallationRecord{SerialNumber: "296930502"}, ir)
+	assert.Equal(t, 3, r.Line())
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+
+	r = NewCSVReader(strings.NewReader("a,b\n1,2\n"), CSVOptions{})
+	_, err = r.Read()
+	assert.EqualError(t, err, "no known column in the CSV header")
+
+	r = NewCSVReader(strings.NewReader("296930501,667769\n"), CSVOptions{
+		Columns:  []Column{{Field: SerialNumber}, {Field: ID}},
+		NoHeader: true,
+	})
+	ir, err = r.Read()
+	require.Nil(t, err)
+	assert.Equal(t, expertview.InstallationRecord{SerialNumber: "296930501", ID: "667769"}, ir)
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.Nil(t, WriteAll(NewJSONWriter(&buf), testRecords[:1]))
+	assert.Equal(t, `[
+{"serialNumber":"296930501","id":"667769","telematic":"Larix Ltda","hardwareProf":"FLX12",`+
+		`"softwareProf":"FLEX-256-V2","dcf":"SQU-8000-TRKS-000000-131001CL.DCF","firmware":"8000-01V114R048.BIN",`+
+		`"key":"293230583 FLX12 FLEX-256-V2 hjashj dshj dahy","username":"asdf"}
+]
+`, buf.String())
+
+	_, err := NewJSONReader(strings.NewReader(`{"serialNumber": "1"}`)).Read()
+	assert.EqualError(t, err, "expected a JSON array, got {")
+}
+
+func TestField(t *testing.T) {
+	var ir expertview.InstallationRecord
+	for _, f := range Fields {
+		require.Nil(t, f.Set(&ir, string(f)))
+	}
+	for _, f := range Fields {
+		assert.Equal(t, string(f), f.Value(&ir))
+	}
+	assert.EqualError(t, Field("nope").Set(&ir, "x"), `unknown field "nope"`)
+	assert.Equal(t, "", Field("nope").Value(&ir))
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/larixsource/go-expertview"
+)
+
+// JSONWriter writes installation records as a JSON array, one record per line.
+type JSONWriter struct {
+	w *bufio.Writer
+	n int
+}
+
+// NewJSONWriter returns a JSONWriter to w.
+func NewJSONWriter(w io.Writer) *JSONWriter {
+	return &JSONWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes ir as the next element of the array.
+func (jw *JSONWriter) Write(ir expertview.InstallationRecord) error {
+	b, err := json.Marshal(ir)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if jw.n == 0 {
+		sep = "[\n"
+	}
+	jw.n++
+	jw.w.WriteString(sep)
+	_, err = jw.w.Write(b)
+	return err
+}
+
+// Close ends the array and flushes it.
+func (jw *JSONWriter) Close() error {
+	if jw.n == 0 {
+		jw.w.WriteString("[]\n")
+	} else {
+		jw.w.WriteString("\n]\n")
+	}
+	return jw.w.Flush()
+}
+
+// JSONReader reads installation records from a JSON array, without holding the whole array in memory.
+type JSONReader struct {
+	dec     *json.Decoder
+	started bool
+}
+
+// NewJSONReader returns a JSONReader from r.
+func NewJSONReader(r io.Reader) *JSONReader {
+	return &JSONReader{dec: json.NewDecoder(r)}
+}
+
+// Read reads the next element of the array.
+func (jr *JSONReader) Read() (expertview.InstallationRecord, error) {
+	var ir expertview.InstallationRecord
+	if !jr.started {
+		tok, err := jr.dec.Token()
+		if err != nil {
+			return ir, err
+		}
+		if d, ok := tok.(json.Delim); !ok || d != '[' {
+			return ir, fmt.Errorf("expected a JSON array, got %v", tok)
+		}
+		jr.started = true
+	}
+	if !jr.dec.More() {
+		if _, err := jr.dec.Token(); err != nil {
+			return ir, err
+		}
+		return ir, io.EOF
+	}
+	err := jr.dec.Decode(&ir)
+	return ir, err
+}
+
+// NDJSONWriter writes installation records as newline delimited JSON, one object per line.
+type NDJSONWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// NewNDJSONWriter returns a NDJSONWriter to w.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	bw := bufio.NewWriter(w)
+	return &NDJSONWriter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+// Write writes ir as a line.
+func (nw *NDJSONWriter) Write(ir expertview.InstallationRecord) error {
+	return nw.enc.Encode(ir)
+}
+
+// Close flushes the lines written.
+func (nw *NDJSONWriter) Close() error {
+	return nw.w.Flush()
+}
+
+// NDJSONReader reads installation records from newline delimited JSON.
+type NDJSONReader struct {
+	dec *json.Decoder
+}
+
+// NewNDJSONReader returns a NDJSONReader from r.
+func NewNDJSONReader(r io.Reader) *NDJSONReader {
+	return &NDJSONReader{dec: json.NewDecoder(r)}
+}
+
+// Read reads the next object.
+func (nr *NDJSONReader) Read() (expertview.InstallationRecord, error) {
+	var ir expertview.InstallationRecord
+	err := nr.dec.Decode(&ir)
+	return ir, err
+}
@@ -0,0 +1,111 @@
+package expertview
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Exception names reported by Expert View in the detail of its SOAP faults.
+const (
+	AuthenticationException        = "AuthenticationException"
+	UnexpectedException            = "UnexpectedException"
+	FirmwareNotSelectableException = "FirmwareNotSelectableException"
+	NoSuchFileException            = "NoSuchFileException"
+)
+
+const faultTimeLayout = "2006/01/02 15:04:05.000"
+
+var faultTimeRe = regexp.MustCompile(`^\s*\[(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}\.\d{3})\]`)
+
+// FaultError is a SOAP fault returned by Expert View. errors.Is reports whether it matches one of the package
+// sentinel errors (ErrAuthentication, ErrUnexpected, ErrFirmwareNotSelectable and ErrNoSuchFile), depending on
+// its exception.
+type FaultError struct {
+	Code   string
+	String string
+	Actor  string
+
+	// Exception is the name of the exception in the fault detail (e.g. AuthenticationException), empty when the
+	// fault has no detail.
+	Exception string
+	// Message is the exception message, as sent by the server.
+	Message string
+	// Time is the server timestamp that prefixes the message (or the fault string), like
+	// "[2016/08/25 00:22:42.101]". The server does not send its time zone, so it is taken as UTC. Zero if there is
+	// no timestamp.
+	Time time.Time
+
+	err error
+}
+
+func (e *FaultError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.String
+	}
+	if e.Exception == "" {
+		return fmt.Sprintf("soap fault %s: %s", e.Code, msg)
+	}
+	return fmt.Sprintf("soap fault %s: %s: %s", e.Code, e.Exception, msg)
+}
+
+// Unwrap returns the sentinel error matching the fault exception, or nil for unknown exceptions.
+func (e *FaultError) Unwrap() error {
+	return e.err
+}
+
+func newFaultError(fault *soapFault, err error) *FaultError {
+	fe := &FaultError{
+		Code:   fault.Code,
+		String: fault.String,
+		Actor:  fault.Actor,
+		err:    err,
+	}
+
+	detail := fault.Detail
+	switch {
+	case detail == nil:
+	case detail.AuthenticationException != nil:
+		fe.Exception, fe.Message = AuthenticationException, detail.AuthenticationException.Message
+	case detail.UnexpectedException != nil:
+		fe.Exception, fe.Message = UnexpectedException, detail.UnexpectedException.Message
+	case detail.FirmwareNotSelectableException != nil:
+		fe.Exception, fe.Message = FirmwareNotSelectableException, detail.FirmwareNotSelectableException.Message
+	case detail.NoSuchFileException != nil:
+		fe.Exception, fe.Message = NoSuchFileException, detail.NoSuchFileException.Message
+	case len(detail.Other) > 0:
+		fe.Exception, fe.Message = detail.Other[0].XMLName.Local, detail.Other[0].Message
+	}
+
+	fe.Time = parseFaultTime(fe.Message)
+	if fe.Time.IsZero() {
+		fe.Time = parseFaultTime(fe.String)
+	}
+	return fe
+}
+
+func parseFaultTime(s string) time.Time {
+	m := faultTimeRe.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(faultTimeLayout, m[1])
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// faultError maps the faults common to every operation.
+func faultError(fault *soapFault) error {
+	detail := fault.Detail
+	switch {
+	case detail != nil && detail.AuthenticationException != nil:
+		return newFaultError(fault, ErrAuthentication)
+	case detail != nil && detail.UnexpectedException != nil:
+		return newFaultError(fault, ErrUnexpected)
+	default:
+		return newFaultError(fault, nil)
+	}
+}
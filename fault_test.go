@@ -0,0 +1,53 @@
+package expertview
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultError(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/getFileResponseFwNotSelectableEx.xml")
+	require.Nil(t, err)
+	_, err = parseGetFile(b)
+
+	var fe *FaultError
+	require.True(t, errors.As(err, &fe))
+	assert.Equal(t, "S:Server", fe.Code)
+	assert.Equal(t, "[2016/08/25 00:22:42.101] FirmwareNotSelectable exception", fe.String)
+	assert.Equal(t, FirmwareNotSelectableException, fe.Exception)
+	assert.Equal(t, "[2016/08/25 00:22:42.101] FirmwareNotSelectable exception", fe.Message)
+	assert.Equal(t, time.Date(2016, 8, 25, 0, 22, 42, 101e6, time.UTC), fe.Time)
+	assert.True(t, errors.Is(err, ErrFirmwareNotSelectable))
+	assert.False(t, errors.Is(err, ErrAuthentication))
+	assert.Equal(t, "soap fault S:Server: FirmwareNotSelectableException: [2016/08/25 00:22:42.101] FirmwareNotSelectable exception", err.Error())
+}
+
+func TestFaultError_UnknownException(t *testing.T) {
+	b := []byte(`<S:Envelope xmlns:S="http://schemas.xmlsoap.org/soap/envelope/">
+    <S:Body>
+        <S:Fault xmlns:ns4="http://www.w3.org/2003/05/soap-envelope">
+            <faultcode>S:Server</faultcode>
+            <faultstring>[2016/08/25 01:02:03.004] Quota exceeded</faultstring>
+            <detail>
+                <ns2:QuotaException xmlns:ns2="http://webservice.expertview.squarell.com/">
+                    <message>Quota exceeded</message>
+                </ns2:QuotaException>
+            </detail>
+        </S:Fault>
+    </S:Body>
+</S:Envelope>`)
+	_, err := parseGetFileList(b)
+
+	var fe *FaultError
+	require.True(t, errors.As(err, &fe))
+	assert.Equal(t, "QuotaException", fe.Exception)
+	assert.Equal(t, "Quota exceeded", fe.Message)
+	assert.Equal(t, time.Date(2016, 8, 25, 1, 2, 3, 4e6, time.UTC), fe.Time)
+	assert.False(t, errors.Is(err, ErrAuthentication))
+	assert.False(t, errors.Is(err, ErrUnexpected))
+}
@@ -0,0 +1,114 @@
+// Package fleet reports on the state of the units installed in a fleet, from their Expert View installation
+// records.
+package fleet
+
+import (
+	"context"
+	"sort"
+
+	"github.com/larixsource/go-expertview"
+)
+
+// DriftStatus tells why a unit is reported by Drift.
+type DriftStatus string
+
+const (
+	// Outdated is a DCF or firmware older than the newest release of its family.
+	Outdated DriftStatus = "outdated"
+	// NotInCatalogue is a DCF or firmware that is not in the file list anymore.
+	NotInCatalogue DriftStatus = "not-in-catalogue"
+)
+
+// Finding is a unit running a DCF or firmware that is not the newest one available.
+type Finding struct {
+	SerialNumber string                `json:"serialNumber"`
+	Kind         expertview.RecordKind `json:"kind"`
+	Family       string                `json:"family"`
+	Status       DriftStatus           `json:"status"`
+	// Installed is the name of the DCF or firmware of the unit.
+	Installed string `json:"installed"`
+	// Latest is the name of the newest release of the family, empty if the family is not in the file list.
+	Latest string `json:"latest,omitempty"`
+}
+
+// DriftOptions tune Drift.
+type DriftOptions struct {
+	// IncludeBeta makes beta DCFs count as the newest release of their family.
+	IncludeBeta bool
+}
+
+// Drift compares the DCF and firmware of every unit with the file list, returning a finding for every DCF or
+// firmware that is outdated or not in the file list. A DCF or firmware is outdated only if the newest release of
+// its own family, the same hardware variant (see expertview.DCFName.Family), is strictly newer: releases of the
+// same day or version are current. Findings are ordered by serial number, then kind.
+func Drift(records []expertview.InstallationRecord, fl expertview.FileList, opts DriftOptions) []Finding {
+	latest := fl.LatestStableReleases()
+	if opts.IncludeBeta {
+		latest = fl.LatestReleases()
+	}
+	type key struct {
+		kind   expertview.RecordKind
+		family string
+	}
+	newest := make(map[key]expertview.Record, len(latest))
+	for _, rec := range latest {
+		newest[key{rec.Kind, rec.Family()}] = rec
+	}
+	catalogue := make(map[key]bool, len(fl.Records))
+	for _, rec := range fl.Records {
+		catalogue[key{rec.Kind, rec.Name}] = true
+	}
+
+	var findings []Finding
+	for _, ir := range records {
+		installed := []expertview.Record{
+			{Kind: expertview.DCFKind, Name: ir.DCF},
+			{Kind: expertview.FirmwareKind, Name: ir.Firmware},
+		}
+		for _, rec := range installed {
+			if rec.Name == "" {
+				continue
+			}
+			f := Finding{
+				SerialNumber: ir.SerialNumber,
+				Kind:         rec.Kind,
+				Family:       rec.Family(),
+				Installed:    rec.Name,
+			}
+			latest, known := newest[key{rec.Kind, f.Family}]
+			if known {
+				f.Latest = latest.Name
+			}
+			switch {
+			case !catalogue[key{rec.Kind, rec.Name}]:
+				f.Status = NotInCatalogue
+			case known && rec.CompareRelease(latest) < 0:
+				f.Status = Outdated
+			default:
+				continue
+			}
+			findings = append(findings, f)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].SerialNumber != findings[j].SerialNumber {
+			return findings[i].SerialNumber < findings[j].SerialNumber
+		}
+		return findings[i].Kind < findings[j].Kind
+	})
+	return findings
+}
+
+// CheckDrift fetches the installation records and the file list from c and returns their Drift.
+func CheckDrift(ctx context.Context, c expertview.Client, opts DriftOptions) ([]Finding, error) {
+	records, err := c.GetInstallationRecordsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fl, err := c.GetFileListContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Drift(records, fl, opts), nil
+}
@@ -0,0 +1,102 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/expertviewtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFileList = expertview.FileList{Records: []expertview.Record{
+	{Kind: expertview.DCFKind, Name: "SQU-8000-OBD-000000-130802CL.DCF", File: "a.dat"},
+	{Kind: expertview.DCFKind, Name: "SQU-8000-OBD-000000-150802CL.DCF", File: "b.dat"},
+	{Kind: expertview.DCFKind, Name: "SQU-8000-OBD-BETA00-160901CL.DCF", File: "c.dat"},
+	{Kind: expertview.FirmwareKind, Name: "8000-01V114R048.BIN", File: "d.dat"},
+	{Kind: expertview.FirmwareKind, Name: "8000-01V115R049.BIN", File: "e.dat"},
+}}
+
+var testRecords = []expertview.InstallationRecord{
+	{SerialNumber: "3", DCF: "SQU-8000-OBD-000000-150802CL.DCF", Firmware: "8000-01V115R049.BIN"},
+	{SerialNumber: "1", DCF: "SQU-8000-OBD-000000-130802CL.DCF", Firmware: "8000-01V115R049.BIN"},
+	{SerialNumber: "2", DCF: "SQU-8000-TRKS-000000-131001CL.DCF", Firmware: "8000-01V114R048.BIN"},
+	{SerialNumber: "4", DCF: "SQU-8000-OBD-000000-140101CL.DCF"},
+}
+
+func TestDrift(t *testing.T) {
+	findings := Drift(testRecords, testFileList, DriftOptions{})
+
+	assert.Equal(t, []Finding{
+		{
+			SerialNumber: "1",
+			Kind:         expertview.DCFKind,
+			Family:       "SQU-8000-OBD-000000",
+			Status:       Outdated,
+			Installed:    "SQU-8000-OBD-000000-130802CL.DCF",
+			Latest:       "SQU-8000-OBD-000000-150802CL.DCF",
+		},
+		{
+			SerialNumber: "2",
+			Kind:         expertview.DCFKind,
+			Family:       "SQU-8000-TRKS-000000",
+			Status:       NotInCatalogue,
+			Installed:    "SQU-8000-TRKS-000000-131001CL.DCF",
+		},
+		{
+			SerialNumber: "2",
+			Kind:         expertview.FirmwareKind,
+			Family:       "8000-01",
+			Status:       Outdated,
+			Installed:    "8000-01V114R048.BIN",
+			Latest:       "8000-01V115R049.BIN",
+		},
+		{
+			SerialNumber: "4",
+			Kind:         expertview.DCFKind,
+			Family:       "SQU-8000-OBD-000000",
+			Status:       NotInCatalogue,
+			Installed:    "SQU-8000-OBD-000000-140101CL.DCF",
+			Latest:       "SQU-8000-OBD-000000-150802CL.DCF",
+		},
+	}, findings)
+}
+
+func TestDrift_IncludeBeta(t *testing.T) {
+	findings := Drift(testRecords[:1], testFileList, DriftOptions{IncludeBeta: true})
+
+	require.Len(t, findings, 1)
+	assert.Equal(t, Outdated, findings[0].Status)
+	assert.Equal(t, "SQU-8000-OBD-BETA00-160901CL.DCF", findings[0].Latest)
+}
+
+func TestDrift_SameDateVariants(t *testing.T) {
+	fl := expertview.FileList{Records: []expertview.Record{
+		{Kind: expertview.DCFKind, Name: "SQU-8000-TRKS-256K-FW49-000000-160621CL.DCF", File: "a.dat"},
+		{Kind: expertview.DCFKind, Name: "SQU-8000-TRKS-256K-FW49-FMS000-160621CL.DCF", File: "b.dat"},
+		{Kind: expertview.DCFKind, Name: "SQU-8000-TRKS-256K-FW49-TPT000-160621CL.DCF", File: "c.dat"},
+	}}
+	records := []expertview.InstallationRecord{
+		{SerialNumber: "1", DCF: "SQU-8000-TRKS-256K-FW49-000000-160621CL.DCF"},
+		{SerialNumber: "2", DCF: "SQU-8000-TRKS-256K-FW49-FMS000-160621CL.DCF"},
+		{SerialNumber: "3", DCF: "SQU-8000-TRKS-256K-FW49-TPT000-160621CL.DCF"},
+	}
+
+	assert.Empty(t, Drift(records, fl, DriftOptions{}))
+}
+
+func TestCheckDrift(t *testing.T) {
+	fake := expertviewtest.NewFake()
+	fake.SetFileList(testFileList)
+	fake.SetInstallationRecords(testRecords)
+
+	findings, err := CheckDrift(context.Background(), fake, DriftOptions{})
+	require.Nil(t, err)
+	assert.Len(t, findings, 4)
+
+	fake.SetError(expertviewtest.GetFileList, expertview.ErrUnexpected)
+	_, err = CheckDrift(context.Background(), fake, DriftOptions{})
+	assert.True(t, errors.Is(err, expertview.ErrUnexpected))
+}
@@ -2,11 +2,16 @@ package expertview
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,6 +38,7 @@ func TestExpertView_GetFile(t *testing.T) {
 		if err != nil {
 			panic(err)
 		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
 		rw.Write(b)
 	}))
 	defer server.Close()
@@ -40,7 +46,7 @@ func TestExpertView_GetFile(t *testing.T) {
 	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
 		Login:    "demo",
 		Password: "demo",
-	})
+	}, WithHTTPClient(server.Client()))
 	require.Nil(t, err)
 	f, err := ev.GetFile("D9984527582012022715184747.dcf")
 	require.Nil(t, err)
@@ -69,6 +75,7 @@ func TestExpertView_GetFileAuthEx(t *testing.T) {
 		if err != nil {
 			panic(err)
 		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
 		rw.Write(b)
 	}))
 	defer server.Close()
@@ -76,10 +83,10 @@ func TestExpertView_GetFileAuthEx(t *testing.T) {
 	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
 		Login:    "demo",
 		Password: "demo",
-	})
+	}, WithHTTPClient(server.Client()))
 	require.Nil(t, err)
 	_, err = ev.GetFile("D9984527582012022715184747.dcf")
-	assert.Equal(t, ErrAuthentication, err)
+	assert.True(t, errors.Is(err, ErrAuthentication), "unexpected error: %v", err)
 }
 
 func TestExpertView_GetFileNotSelectableEx(t *testing.T) {
@@ -103,6 +110,7 @@ func TestExpertView_GetFileNotSelectableEx(t *testing.T) {
 		if err != nil {
 			panic(err)
 		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
 		rw.Write(b)
 	}))
 	defer server.Close()
@@ -110,8 +118,175 @@ func TestExpertView_GetFileNotSelectableEx(t *testing.T) {
 	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
 		Login:    "demo",
 		Password: "demo",
-	})
+	}, WithHTTPClient(server.Client()))
 	require.Nil(t, err)
 	_, err = ev.GetFile("D9984527582012022715184747.dcf")
-	assert.Equal(t, ErrFirmwareNotSelectable, err)
+	assert.True(t, errors.Is(err, ErrFirmwareNotSelectable), "unexpected error: %v", err)
+}
+
+func TestExpertView_GetFileNoSuchFileEx(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rb, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		ok := bytes.Contains(rb, []byte("<sq:getFile><login>demo</login><password>fe01ce2a7fbac8fafaed7c982a04e229</password><version>2.5.0</version><filename>D0000000000000000000000000.dcf</filename></sq:getFile>"))
+		if !ok {
+			t.Errorf("invalid soap call: %s", rb)
+			t.FailNow()
+		}
+
+		f, err := os.Open("testdata/getFileResponseNoSuchFileEx.xml")
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			panic(err)
+		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		rw.Write(b)
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	_, err = ev.GetFile("D0000000000000000000000000.dcf")
+	assert.True(t, errors.Is(err, ErrNoSuchFile), "unexpected error: %v", err)
+	assert.False(t, errors.Is(err, ErrAuthentication))
+}
+
+func TestExpertView_GetFileContextDeadline(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = ev.GetFileContext(ctx, "D9984527582012022715184747.dcf")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+}
+
+func TestExpertView_GetFileTo(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rb, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		ok := bytes.Contains(rb, []byte("<sq:getFile><login>demo</login><password>fe01ce2a7fbac8fafaed7c982a04e229</password><version>2.5.0</version><filename>D9984527582012022715184747.dcf</filename></sq:getFile>"))
+		if !ok {
+			t.Errorf("invalid soap call: %s", rb)
+			t.FailNow()
+		}
+
+		f, err := os.Open("testdata/getFileResponse.xml")
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			panic(err)
+		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		rw.Write(b)
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	var buf bytes.Buffer
+	n, err := ev.GetFileTo(context.Background(), "D9984527582012022715184747.dcf", &buf)
+	require.Nil(t, err)
+
+	assert.Equal(t, int64(5), n)
+	assert.Equal(t, []byte("hello"), buf.Bytes())
+}
+
+func TestExpertView_GetFileToLarge(t *testing.T) {
+	data := make([]byte, 3<<20+1)
+	_, err := rand.Read(data)
+	require.Nil(t, err)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		rw.Write([]byte(`<S:Envelope xmlns:S="http://schemas.xmlsoap.org/soap/envelope/"><S:Body><ns2:getFileResponse xmlns:ns2="http://webservice.expertview.squarell.com/"><return>`))
+		enc := base64.StdEncoding.EncodeToString(data)
+		for len(enc) > 76 {
+			rw.Write([]byte(enc[:76] + "\r\n"))
+			enc = enc[76:]
+		}
+		rw.Write([]byte(enc + `</return></ns2:getFileResponse></S:Body></S:Envelope>`))
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	var buf bytes.Buffer
+	n, err := ev.GetFileTo(context.Background(), "8000-01V114R048.BIN", &buf)
+	require.Nil(t, err)
+
+	assert.Equal(t, int64(len(data)), n)
+	assert.True(t, bytes.Equal(data, buf.Bytes()))
+}
+
+func TestExpertView_GetFileToNotSelectableEx(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		f, err := os.Open("testdata/getFileResponseFwNotSelectableEx.xml")
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			panic(err)
+		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		rw.Write(b)
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	var buf bytes.Buffer
+	_, err = ev.GetFileTo(context.Background(), "D9984527582012022715184747.dcf", &buf)
+	assert.True(t, errors.Is(err, ErrFirmwareNotSelectable), "unexpected error: %v", err)
+	assert.Equal(t, 0, buf.Len())
+}
+
+func TestStreamGetFile_Empty(t *testing.T) {
+	for _, body := range []string{
+		`<ns2:getFileResponse xmlns:ns2="http://webservice.expertview.squarell.com/"/>`,
+		`<ns2:getFileResponse xmlns:ns2="http://webservice.expertview.squarell.com/"><return></return></ns2:getFileResponse>`,
+	} {
+		resp := `<S:Envelope xmlns:S="http://schemas.xmlsoap.org/soap/envelope/"><S:Body>` + body + `</S:Body></S:Envelope>`
+		_, parseErr := parseGetFile([]byte(resp))
+		require.NotNil(t, parseErr)
+		var buf bytes.Buffer
+		_, err := streamGetFile(bytes.NewReader([]byte(resp)), &buf)
+		assert.Equal(t, parseErr, err, body)
+	}
 }
@@ -2,6 +2,7 @@ package expertview
 
 import (
 	"bytes"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -33,6 +34,7 @@ func TestExpertView_GetFileList(t *testing.T) {
 		if err != nil {
 			panic(err)
 		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
 		rw.Write(b)
 	}))
 	defer server.Close()
@@ -40,7 +42,7 @@ func TestExpertView_GetFileList(t *testing.T) {
 	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
 		Login:    "demo",
 		Password: "demo",
-	})
+	}, WithHTTPClient(server.Client()))
 	require.Nil(t, err)
 	fl, err := ev.GetFileList()
 	require.Nil(t, err)
@@ -75,6 +77,7 @@ func TestExpertView_GetFileListAuthEx(t *testing.T) {
 		if err != nil {
 			panic(err)
 		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
 		rw.Write(b)
 	}))
 	defer server.Close()
@@ -82,8 +85,8 @@ func TestExpertView_GetFileListAuthEx(t *testing.T) {
 	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
 		Login:    "demo",
 		Password: "demo",
-	})
+	}, WithHTTPClient(server.Client()))
 	require.Nil(t, err)
 	_, err = ev.GetFileList()
-	assert.Equal(t, ErrAuthentication, err)
+	assert.True(t, errors.Is(err, ErrAuthentication), "unexpected error: %v", err)
 }
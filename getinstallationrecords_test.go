@@ -2,6 +2,7 @@ package expertview
 
 import (
 	"bytes"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -33,6 +34,7 @@ func TestExpertView_GetInstallationRecords(t *testing.T) {
 		if err != nil {
 			panic(err)
 		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
 		rw.Write(b)
 	}))
 	defer server.Close()
@@ -40,7 +42,7 @@ func TestExpertView_GetInstallationRecords(t *testing.T) {
 	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
 		Login:    "demo",
 		Password: "demo",
-	})
+	}, WithHTTPClient(server.Client()))
 	require.Nil(t, err)
 	fl, err := ev.GetInstallationRecords()
 	require.Nil(t, err)
@@ -78,6 +80,7 @@ func TestExpertView_GetInstallationRecordsAuthEx(t *testing.T) {
 		if err != nil {
 			panic(err)
 		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
 		rw.Write(b)
 	}))
 	defer server.Close()
@@ -85,8 +88,8 @@ func TestExpertView_GetInstallationRecordsAuthEx(t *testing.T) {
 	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
 		Login:    "demo",
 		Password: "demo",
-	})
+	}, WithHTTPClient(server.Client()))
 	require.Nil(t, err)
 	_, err = ev.GetInstallationRecords()
-	assert.Equal(t, ErrAuthentication, err)
+	assert.True(t, errors.Is(err, ErrAuthentication), "unexpected error: %v", err)
 }
@@ -0,0 +1,81 @@
+package expertview
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpertView_GetVersion(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rb, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		ok := bytes.Contains(rb, []byte("<sq:getVersion/>"))
+		if !ok {
+			t.Errorf("invalid soap call: %s", rb)
+			t.FailNow()
+		}
+
+		f, err := os.Open("testdata/getVersionResponse.xml")
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			panic(err)
+		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		rw.Write(b)
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	v, err := ev.GetVersion()
+	require.Nil(t, err)
+
+	assert.Equal(t, Version{Major: 2, Minor: 6, Patch: 1}, v)
+	assert.Equal(t, "2.6.1", v.String())
+
+	current, err := ParseVersion(ev.Version())
+	require.Nil(t, err)
+	assert.True(t, v.Newer(current))
+}
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("2.5")
+	require.Nil(t, err)
+	assert.Equal(t, Version{Major: 2, Minor: 5}, v)
+
+	_, err = ParseVersion("2.x.0")
+	assert.NotNil(t, err)
+	_, err = ParseVersion("")
+	assert.NotNil(t, err)
+	_, err = ParseVersion("1.2.3.4")
+	assert.NotNil(t, err)
+}
+
+func TestVersion_Compare(t *testing.T) {
+	v250 := Version{Major: 2, Minor: 5, Patch: 0}
+	v251 := Version{Major: 2, Minor: 5, Patch: 1}
+	v300 := Version{Major: 3}
+
+	assert.Equal(t, 0, v250.Compare(v250))
+	assert.Equal(t, -1, v250.Compare(v251))
+	assert.Equal(t, 1, v300.Compare(v251))
+	assert.True(t, v250.Less(v251))
+	assert.True(t, v300.Newer(v251))
+	assert.True(t, v251.Equal(Version{Major: 2, Minor: 5, Patch: 1}))
+}
@@ -0,0 +1,42 @@
+package expertview
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxHTTPErrorBody is the most of the response body kept by an HTTPError.
+const maxHTTPErrorBody = 4 << 10
+
+// HTTPError is a response that the server (or a proxy in between) sent instead of a SOAP message: a non-2xx
+// status without a SOAP fault, or a body that is not XML. SOAP faults are returned as *FaultError instead.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	// Body holds the start of the response body, truncated to 4 KiB.
+	Body []byte
+}
+
+func (e *HTTPError) Error() string {
+	ct := e.Header.Get("Content-Type")
+	if ct == "" {
+		return fmt.Sprintf("unexpected http response: %s", e.Status)
+	}
+	return fmt.Sprintf("unexpected http response: %s (%s)", e.Status, ct)
+}
+
+// isXMLContentType reports whether ct may hold a SOAP message. An empty content type is accepted, since some
+// servers do not send one.
+func isXMLContentType(ct string) bool {
+	if ct == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mt == "text/xml" || mt == "application/xml" || strings.HasSuffix(mt, "+xml")
+}
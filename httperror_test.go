@@ -0,0 +1,87 @@
+package expertview
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpertView_HTTPErrorBadGateway(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/html")
+		rw.WriteHeader(http.StatusBadGateway)
+		rw.Write([]byte("<html><body><h1>502 Bad Gateway</h1>" + strings.Repeat(" ", 8<<10) + "</body></html>"))
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	_, err = ev.GetFileList()
+
+	var he *HTTPError
+	require.True(t, errors.As(err, &he), "unexpected error: %v", err)
+	assert.Equal(t, http.StatusBadGateway, he.StatusCode)
+	assert.Equal(t, "text/html", he.Header.Get("Content-Type"))
+	assert.Len(t, he.Body, maxHTTPErrorBody)
+	assert.True(t, strings.HasPrefix(string(he.Body), "<html><body><h1>502 Bad Gateway</h1>"))
+	assert.Equal(t, "unexpected http response: 502 Bad Gateway (text/html)", err.Error())
+}
+
+func TestExpertView_HTTPErrorContentType(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.Write([]byte("<html><body>Maintenance</body></html>"))
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	_, err = ev.GetInstallationRecords()
+
+	var he *HTTPError
+	require.True(t, errors.As(err, &he), "unexpected error: %v", err)
+	assert.Equal(t, http.StatusOK, he.StatusCode)
+	assert.Equal(t, []byte("<html><body>Maintenance</body></html>"), he.Body)
+}
+
+func TestExpertView_HTTPErrorFaultStatus(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		f, err := os.Open("testdata/getFileListResponseAuthEx.xml")
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			panic(err)
+		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write(b)
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	_, err = ev.GetFileList()
+
+	var he *HTTPError
+	assert.False(t, errors.As(err, &he))
+	assert.True(t, errors.Is(err, ErrAuthentication), "unexpected error: %v", err)
+}
@@ -0,0 +1,159 @@
+// Package importer uploads installation records read from CSV or JSON, like a workshop spreadsheet, with
+// PostInstallRecords. Every row is validated against the file list before anything is posted.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/export"
+)
+
+// Row is an installation record read from the input.
+type Row struct {
+	// N is the line of a CSV row, or the position, from 1, of a JSON record.
+	N      int
+	Record expertview.InstallationRecord
+}
+
+// RowError is an error in a row of the input.
+type RowError struct {
+	N   int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.N, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// RowErrors are all the rows that failed validation.
+type RowErrors []*RowError
+
+func (errs RowErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d invalid rows:\n%s", len(errs), strings.Join(msgs, "\n"))
+}
+
+// ReadRows reads all the records of r. Rows are numbered by line if r has a Line method, like export.CSVReader,
+// or by position otherwise.
+func ReadRows(r export.Reader) ([]Row, error) {
+	liner, _ := r.(interface{ Line() int })
+	var rows []Row
+	for {
+		ir, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		n := len(rows) + 1
+		switch {
+		case err != nil && liner != nil:
+			// CSV errors have their line already
+			return nil, err
+		case err != nil:
+			return nil, &RowError{N: n, Err: err}
+		case liner != nil:
+			n = liner.Line()
+		}
+		rows = append(rows, Row{N: n, Record: ir})
+	}
+}
+
+// Validate checks every row: the serial number, DCF and firmware are required, the DCF and firmware must be in
+// the file list, serial numbers must not repeat and keys must agree with the profiles of their record.
+func Validate(rows []Row, fl expertview.FileList) RowErrors {
+	known := make(map[expertview.Record]bool, len(fl.Records))
+	for _, rec := range fl.Records {
+		known[expertview.Record{Kind: rec.Kind, Name: rec.Name}] = true
+	}
+	seen := make(map[string]int, len(rows))
+
+	var errs RowErrors
+	for _, row := range rows {
+		if err := validate(row.Record, known, seen); err != nil {
+			errs = append(errs, &RowError{N: row.N, Err: err})
+		}
+		if sn := row.Record.SerialNumber; sn != "" {
+			if _, ok := seen[sn]; !ok {
+				seen[sn] = row.N
+			}
+		}
+	}
+	return errs
+}
+
+func validate(ir expertview.InstallationRecord, known map[expertview.Record]bool, seen map[string]int) error {
+	switch {
+	case ir.SerialNumber == "":
+		return errors.New("serial number required")
+	case seen[ir.SerialNumber] != 0:
+		return fmt.Errorf("serial number %s repeats row %d", ir.SerialNumber, seen[ir.SerialNumber])
+	case ir.DCF == "":
+		return errors.New("DCF required")
+	case ir.Firmware == "":
+		return errors.New("firmware required")
+	case !known[expertview.Record{Kind: expertview.DCFKind, Name: ir.DCF}]:
+		return fmt.Errorf("DCF %s not in the file list", ir.DCF)
+	case !known[expertview.Record{Kind: expertview.FirmwareKind, Name: ir.Firmware}]:
+		return fmt.Errorf("firmware %s not in the file list", ir.Firmware)
+	}
+	return ir.CheckKey()
+}
+
+// Importer validates installation records and posts them.
+type Importer struct {
+	Client expertview.Client
+	// DryRun, when set, receives the INSTALLATIONS XML document that would be posted (see
+	// expertview.InstallRecordsPayload), and nothing is posted. Credentials are never written.
+	DryRun io.Writer
+}
+
+// Import reads the records of r, validates them against the file list of the client and posts them all at once.
+// Nothing is posted if any row is invalid; the error is then a RowErrors with every invalid row. It returns the
+// number of records posted, or that would be posted on a dry run.
+func (im *Importer) Import(ctx context.Context, r export.Reader) (int, error) {
+	rows, err := ReadRows(r)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, errors.New("no installation records to import")
+	}
+	fl, err := im.Client.GetFileListContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if errs := Validate(rows, fl); len(errs) > 0 {
+		return 0, errs
+	}
+
+	records := make([]expertview.InstallationRecord, len(rows))
+	for i, row := range rows {
+		records[i] = row.Record
+	}
+	if im.DryRun == nil {
+		if err = im.Client.PostInstallRecordsContext(ctx, records); err != nil {
+			return 0, err
+		}
+		return len(records), nil
+	}
+
+	payload, err := expertview.InstallRecordsPayload(records)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = im.DryRun.Write(append(payload, '\n')); err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
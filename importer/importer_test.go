@@ -0,0 +1,121 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/cache"
+	"github.com/larixsource/go-expertview/expertviewtest"
+	"github.com/larixsource/go-expertview/export"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFileList = expertview.FileList{Records: []expertview.Record{
+	{Kind: expertview.DCFKind, Name: "SQU-8000-TRKS-000000-131001CL.DCF", File: "D9984527582012022715184747.dcf"},
+	{Kind: expertview.FirmwareKind, Name: "8000-01V114R048.BIN", File: "F0000000000000000000000001.bin"},
+}}
+
+const testCSV = `Serial Number,DCF,Firmware,Hardware,Software,Key
+296930501,SQU-8000-TRKS-000000-131001CL.DCF,8000-01V114R048.BIN,FLX12,FLEX-256-V2,293230583 FLX12 FLEX-256-V2 abc
+296930502,SQU-8000-TRKS-000000-131001CL.DCF,8000-01V114R048.BIN,,,
+`
+
+var testColumns = []export.Column{
+	{Field: export.SerialNumber, Header: "Serial Number"},
+	{Field: export.DCF, Header: "DCF"},
+	{Field: export.Firmware, Header: "Firmware"},
+	{Field: export.HardwareProf, Header: "Hardware"},
+	{Field: export.SoftwareProf, Header: "Software"},
+	{Field: export.Key, Header: "Key"},
+}
+
+func csvReader(s string) export.Reader {
+	return export.NewCSVReader(strings.NewReader(s), export.CSVOptions{Columns: testColumns})
+}
+
+func TestImport(t *testing.T) {
+	fake := expertviewtest.NewFake()
+	fake.SetFileList(testFileList)
+
+	im := &Importer{Client: fake}
+	n, err := im.Import(context.Background(), csvReader(testCSV))
+	require.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	records, err := fake.GetInstallationRecords()
+	require.Nil(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, expertview.InstallationRecord{
+		SerialNumber: "296930501",
+		HardwareProf: "FLX12",
+		SoftwareProf: "FLEX-256-V2",
+		DCF:          "SQU-8000-TRKS-000000-131001CL.DCF",
+		Firmware:     "8000-01V114R048.BIN",
+		Key:          "293230583 FLX12 FLEX-256-V2 abc",
+	}, records[0])
+}
+
+func TestImport_Invalid(t *testing.T) {
+	fake := expertviewtest.NewFake()
+	fake.SetFileList(testFileList)
+
+	in := testCSV +
+		",SQU-8000-TRKS-000000-131001CL.DCF,8000-01V114R048.BIN,,,\n" +
+		"296930501,SQU-8000-TRKS-000000-131001CL.DCF,8000-01V114R048.BIN,,,\n" +
+		"296930503,SQU-8000-TRKS-000000-131002CL.DCF,8000-01V114R048.BIN,,,\n" +
+		"296930504,SQU-8000-TRKS-000000-131001CL.DCF,,,,\n" +
+		"296930505,SQU-8000-TRKS-000000-131001CL.DCF,8000-01V114R048.BIN,FLX13,FLEX-256-V2,293230583 FLX12 FLEX-256-V2\n"
+	im := &Importer{Client: fake}
+	n, err := im.Import(context.Background(), csvReader(in))
+	assert.Equal(t, 0, n)
+	var errs RowErrors
+	require.True(t, errors.As(err, &errs), "unexpected error: %v", err)
+	assert.Equal(t, `5 invalid rows:
+row 4: serial number required
+row 5: serial number 296930501 repeats row 2
+row 6: DCF SQU-8000-TRKS-000000-131002CL.DCF not in the file list
+row 7: firmware required
+row 8: key hardware profile "FLX12" differs from "FLX13"`, err.Error())
+
+	records, err := fake.GetInstallationRecords()
+	require.Nil(t, err)
+	assert.Empty(t, records)
+}
+
+func TestImport_DryRun(t *testing.T) {
+	fake := expertviewtest.NewFake()
+	fake.SetFileList(testFileList)
+
+	for _, client := range []expertview.Client{fake, cache.New(fake, cache.NewMemoryStore(), time.Minute)} {
+		var buf bytes.Buffer
+		im := &Importer{Client: client, DryRun: &buf}
+		n, err := im.Import(context.Background(), csvReader(testCSV))
+		require.Nil(t, err)
+		assert.Equal(t, 2, n)
+		assert.True(t, strings.HasPrefix(buf.String(), `<?xml version="1.0" encoding="UTF-8"?>`+"\n<INSTALLATIONS>"), buf.String())
+		assert.Contains(t, buf.String(), `<RECORD SN="296930502">`)
+		assert.NotContains(t, buf.String(), "password")
+
+		records, err := fake.GetInstallationRecords()
+		require.Nil(t, err)
+		assert.Empty(t, records)
+	}
+}
+
+func TestReadRows_JSON(t *testing.T) {
+	rows, err := ReadRows(export.NewJSONReader(strings.NewReader(`[{"serialNumber": "1"}, {"serialNumber": "2"}]`)))
+	require.Nil(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, Row{N: 2, Record: expertview.InstallationRecord{SerialNumber: "2"}}, rows[1])
+
+	_, err = ReadRows(export.NewJSONReader(strings.NewReader(`[{"serialNumber": "1"}, {"serialNumber": 2}]`)))
+	var re *RowError
+	require.True(t, errors.As(err, &re), "unexpected error: %v", err)
+	assert.Equal(t, 2, re.N)
+}
@@ -0,0 +1,85 @@
+// Package atomicfile writes files through a temporary file that is synced and renamed into place, so readers see
+// either the old or the complete new contents, even after a crash.
+package atomicfile
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// mode is given to new files. ioutil.TempFile creates them 0600, which would keep other users from reading them.
+const mode = 0644
+
+// File is a temporary file that becomes a named file on Commit.
+type File struct {
+	*os.File
+	done bool
+}
+
+// Create returns a new temporary file in dir, which should be the directory of the final file so Commit can
+// rename it.
+func Create(dir string) (*File, error) {
+	f, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	return &File{File: f}, nil
+}
+
+// Commit syncs and closes f and renames it to path. The file keeps the mode of the file it replaces, or gets
+// 0644.
+func (f *File) Commit(path string) error {
+	defer f.Abort()
+	perm := os.FileMode(mode)
+	if fi, err := os.Stat(path); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	if err := f.Chmod(perm); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	f.done = true
+	return nil
+}
+
+// Abort closes and removes f, unless it has been committed. It is safe to call more than once, so it can be
+// deferred right after Create.
+func (f *File) Abort() {
+	if f.done {
+		return
+	}
+	f.done = true
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// WriteFile creates path with the contents written by write, replacing it atomically.
+func WriteFile(path string, write func(w io.Writer) error) error {
+	f, err := Create(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	if err = write(f); err != nil {
+		return err
+	}
+	return f.Commit(path)
+}
+
+// Write creates path with the given contents, replacing it atomically.
+func Write(path string, data []byte) error {
+	return WriteFile(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
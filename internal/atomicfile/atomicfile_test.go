@@ -0,0 +1,71 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeString(s string) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+
+	require.Nil(t, WriteFile(path, writeString("hello")))
+	b, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, "hello", string(b))
+	fi, err := os.Stat(path)
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0644), fi.Mode().Perm())
+
+	// the mode of the replaced file is kept
+	require.Nil(t, os.Chmod(path, 0640))
+	require.Nil(t, Write(path, []byte("bye")))
+	fi, err = os.Stat(path)
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0640), fi.Mode().Perm())
+
+	// a failed write leaves the file and no temporary file behind
+	failed := errors.New("failed")
+	err = WriteFile(path, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return failed
+	})
+	assert.Equal(t, failed, err)
+	b, err = ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, "bye", string(b))
+	infos, err := ioutil.ReadDir(dir)
+	require.Nil(t, err)
+	assert.Len(t, infos, 1)
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	f, err := Create(dir)
+	require.Nil(t, err)
+	_, err = f.WriteString("hello")
+	require.Nil(t, err)
+	require.Nil(t, f.Commit(filepath.Join(dir, "file")))
+	f.Abort()
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "file"))
+	require.Nil(t, err)
+	assert.Equal(t, "hello", string(b))
+	infos, err := ioutil.ReadDir(dir)
+	require.Nil(t, err)
+	assert.Len(t, infos, 1)
+}
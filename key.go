@@ -0,0 +1,74 @@
+package expertview
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InstallationKey is the parsed Key of an installation record, like "293230583 FLX12 FLEX-256-V2 hjashj dshj
+// dahy": a unit number (293230583), the hardware profile (FLX12), the software profile (FLEX-256-V2) and
+// free-form tokens.
+type InstallationKey struct {
+	// Raw is the key as given. It is the only field set when Valid is false.
+	Raw string
+	// Valid reports whether the key could be parsed.
+	Valid bool
+
+	UnitNumber   string
+	HardwareProf string
+	SoftwareProf string
+	Tokens       []string
+}
+
+// ParseKey parses the Key of an installation record. Keys that do not start with a numeric unit number followed
+// by the hardware and software profiles are returned with just Raw set.
+func ParseKey(key string) InstallationKey {
+	k := InstallationKey{Raw: key}
+	fields := strings.Fields(key)
+	if len(fields) < 3 || !isDigits(fields[0]) {
+		return k
+	}
+	k.Valid = true
+	k.UnitNumber, k.HardwareProf, k.SoftwareProf = fields[0], fields[1], fields[2]
+	if len(fields) > 3 {
+		k.Tokens = fields[3:]
+	}
+	return k
+}
+
+// String returns the key as sent to Expert View: its fields separated by single spaces, or Raw if the key is not
+// valid. Keys parsed from single-spaced strings give back the same string.
+func (k InstallationKey) String() string {
+	if !k.Valid {
+		return k.Raw
+	}
+	fields := append([]string{k.UnitNumber, k.HardwareProf, k.SoftwareProf}, k.Tokens...)
+	return strings.Join(fields, " ")
+}
+
+// CheckKey checks that the hardware and software profiles packed in the record key agree with HardwareProf and
+// SoftwareProf. Records without a key pass; records with a key that cannot be parsed do not.
+func (ir InstallationRecord) CheckKey() error {
+	if ir.Key == "" {
+		return nil
+	}
+	k := ParseKey(ir.Key)
+	switch {
+	case !k.Valid:
+		return fmt.Errorf("invalid key %q", ir.Key)
+	case k.HardwareProf != ir.HardwareProf:
+		return fmt.Errorf("key hardware profile %q differs from %q", k.HardwareProf, ir.HardwareProf)
+	case k.SoftwareProf != ir.SoftwareProf:
+		return fmt.Errorf("key software profile %q differs from %q", k.SoftwareProf, ir.SoftwareProf)
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
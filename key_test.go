@@ -0,0 +1,72 @@
+package expertview
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKey(t *testing.T) {
+	k := ParseKey("293230583 FLX12 FLEX-256-V2 hjashj dshj dahy")
+	assert.Equal(t, InstallationKey{
+		Raw:          "293230583 FLX12 FLEX-256-V2 hjashj dshj dahy",
+		Valid:        true,
+		UnitNumber:   "293230583",
+		HardwareProf: "FLX12",
+		SoftwareProf: "FLEX-256-V2",
+		Tokens:       []string{"hjashj", "dshj", "dahy"},
+	}, k)
+	assert.Equal(t, "293230583 FLX12 FLEX-256-V2 hjashj dshj dahy", k.String())
+
+	k = ParseKey("293230583 FLX12 FLEX-256-V2")
+	assert.True(t, k.Valid)
+	assert.Nil(t, k.Tokens)
+	assert.Equal(t, "293230583 FLX12 FLEX-256-V2", k.String())
+
+	k.SoftwareProf = "FLEX-512-V2"
+	k.Tokens = []string{"abc"}
+	assert.Equal(t, "293230583 FLX12 FLEX-512-V2 abc", k.String())
+
+	for _, key := range []string{"", "FLX12 FLEX-256-V2", "X293230583 FLX12 FLEX-256-V2"} {
+		k = ParseKey(key)
+		assert.False(t, k.Valid, key)
+		assert.Equal(t, key, k.String())
+	}
+}
+
+func TestInstallationRecord_CheckKey(t *testing.T) {
+	ir := InstallationRecord{
+		HardwareProf: "FLX12",
+		SoftwareProf: "FLEX-256-V2",
+		Key:          "293230583 FLX12 FLEX-256-V2 hjashj dshj dahy",
+	}
+	assert.Nil(t, ir.CheckKey())
+
+	ir.HardwareProf = "FLX13"
+	assert.EqualError(t, ir.CheckKey(), `key hardware profile "FLX12" differs from "FLX13"`)
+
+	ir.HardwareProf, ir.SoftwareProf = "FLX12", "FLEX-512-V2"
+	assert.EqualError(t, ir.CheckKey(), `key software profile "FLEX-256-V2" differs from "FLEX-512-V2"`)
+
+	ir.Key = "nope"
+	assert.EqualError(t, ir.CheckKey(), `invalid key "nope"`)
+
+	ir.Key = ""
+	assert.Nil(t, ir.CheckKey())
+}
+
+func TestParseKey_InstallRecords(t *testing.T) {
+	resp, err := ioutil.ReadFile("testdata/getInstallRecordsResponse.xml")
+	require.Nil(t, err)
+	records, err := parseGetInstallRecords(resp)
+	require.Nil(t, err)
+
+	for _, ir := range records {
+		k := ParseKey(ir.Key)
+		assert.True(t, k.Valid, ir.Key)
+		assert.Equal(t, ir.Key, k.String())
+		assert.Nil(t, ir.CheckKey(), ir.SerialNumber)
+	}
+}
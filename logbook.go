@@ -0,0 +1,101 @@
+package expertview
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type LogBookActivity string
+
+const (
+	DrivingActivity LogBookActivity = "DRIVING"
+	WorkActivity    LogBookActivity = "WORK"
+	AvailActivity   LogBookActivity = "AVAILABLE"
+	RestActivity    LogBookActivity = "REST"
+)
+
+func (a LogBookActivity) valid() bool {
+	switch a {
+	case DrivingActivity, WorkActivity, AvailActivity, RestActivity:
+		return true
+	}
+	return false
+}
+
+// LogBookEntry is a single driver activity period. Odometer values are in kilometers and optional (zero) for
+// activities other than driving.
+type LogBookEntry struct {
+	Start         time.Time
+	End           time.Time
+	Activity      LogBookActivity
+	StartOdometer float64
+	EndOdometer   float64
+	Description   string
+}
+
+// Validate checks that the entry has a known activity and a consistent time and odometer range.
+func (e *LogBookEntry) Validate() error {
+	switch {
+	case !e.Activity.valid():
+		return fmt.Errorf("unknown activity %q", e.Activity)
+	case e.Start.IsZero() || e.End.IsZero():
+		return errors.New("start and end required")
+	case e.End.Before(e.Start):
+		return errors.New("end before start")
+	case e.StartOdometer < 0 || e.EndOdometer < 0:
+		return errors.New("negative odometer")
+	case e.EndOdometer < e.StartOdometer:
+		return errors.New("end odometer lower than start odometer")
+	}
+	return nil
+}
+
+// LogBook groups the activity entries of a driver and vehicle, recorded by the unit with the given serial number.
+type LogBook struct {
+	SerialNumber string
+	Driver       string
+	Vehicle      string
+	Entries      []LogBookEntry
+}
+
+// Validate checks that the logbook identifies its unit and that its entries are valid and do not overlap. Entries
+// may be in any order.
+func (lb *LogBook) Validate() error {
+	if lb.SerialNumber == "" {
+		return errors.New("serial number required")
+	}
+	if len(lb.Entries) == 0 {
+		return errors.New("no entries")
+	}
+	for i := range lb.Entries {
+		if err := lb.Entries[i].Validate(); err != nil {
+			return fmt.Errorf("entry %d: %s", i, err)
+		}
+	}
+
+	// check the overlaps in start order, reporting the entries by their index in lb.Entries
+	order := make([]int, len(lb.Entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return lb.Entries[order[i]].Start.Before(lb.Entries[order[j]].Start)
+	})
+	for k := 1; k < len(order); k++ {
+		prev, cur := order[k-1], order[k]
+		if lb.Entries[cur].Start.Before(lb.Entries[prev].End) {
+			return fmt.Errorf("entry %d: overlaps entry %d", cur, prev)
+		}
+	}
+	return nil
+}
+
+// LogBookResult is the outcome reported by the server for one of the logbooks sent with PutLogBooks.
+type LogBookResult struct {
+	SerialNumber string
+	Accepted     int
+	Rejected     int
+	Message      string
+}
@@ -0,0 +1,63 @@
+package mirror
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/internal/atomicfile"
+)
+
+// ManifestName is the name of the manifest file kept in the mirror directory.
+const ManifestName = "manifest.json"
+
+// Entry describes a mirrored file.
+type Entry struct {
+	Kind   expertview.RecordKind `json:"kind"`
+	Name   string                `json:"name"`
+	File   string                `json:"file"`
+	Size   int64                 `json:"size"`
+	SHA256 string                `json:"sha256"`
+	Synced time.Time             `json:"synced"`
+}
+
+// Manifest lists the mirrored files, by Record.File.
+type Manifest struct {
+	Updated time.Time         `json:"updated"`
+	Files   map[string]*Entry `json:"files"`
+}
+
+// LoadManifest reads the manifest of the mirror in dir. A missing manifest is returned as an empty one.
+func LoadManifest(dir string) (*Manifest, error) {
+	m := &Manifest{Files: make(map[string]*Entry)}
+	b, err := ioutil.ReadFile(filepath.Join(dir, ManifestName))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]*Entry)
+	}
+	return m, nil
+}
+
+// save writes the manifest to dir atomically.
+func (m *Manifest) save(dir string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(filepath.Join(dir, ManifestName), func(w io.Writer) error {
+		_, err := w.Write(append(b, '\n'))
+		return err
+	})
+}
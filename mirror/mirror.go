@@ -0,0 +1,221 @@
+// Package mirror keeps a local copy of the DCF and firmware files available in Expert View.
+package mirror
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/internal/atomicfile"
+)
+
+// PruneMode tells what Sync does with mirrored files that are no longer in the file list.
+type PruneMode int
+
+const (
+	// PruneKeep leaves them in place, and in the manifest.
+	PruneKeep PruneMode = iota
+	// PruneDelete deletes them.
+	PruneDelete
+	// PruneArchive moves them to the archive directory.
+	PruneArchive
+)
+
+// DefaultArchiveDir is the archive directory, relative to the mirror directory, used when Mirror.ArchiveDir is
+// empty.
+const DefaultArchiveDir = "archive"
+
+// Mirror syncs the files of an Expert View account into Dir. Files are stored by their Record.File name, and
+// described in a manifest (see Manifest).
+type Mirror struct {
+	Client expertview.Client
+	Dir    string
+
+	Prune PruneMode
+	// ArchiveDir is where PruneArchive moves files to. Relative paths are taken from Dir.
+	ArchiveDir string
+	// Verify makes Sync check the SHA-256 of files already mirrored, downloading them again if it does not match
+	// the manifest. Otherwise only their size is checked.
+	Verify bool
+}
+
+// Result reports what a Sync did.
+type Result struct {
+	Downloaded []string
+	Unchanged  []string
+	Pruned     []string
+	// Failed holds the files that could not be downloaded or pruned.
+	Failed map[string]error
+}
+
+// Sync downloads the files in the file list that are missing or changed locally, and prunes the files that are
+// no longer listed. A file that fails does not stop the others: Sync goes on and returns an error along with the
+// Result, whose Failed field tells which files failed. The manifest is saved even if some files failed.
+func (m *Mirror) Sync(ctx context.Context) (Result, error) {
+	res := Result{Failed: make(map[string]error)}
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return res, err
+	}
+	manifest, err := LoadManifest(m.Dir)
+	if err != nil {
+		return res, fmt.Errorf("error loading manifest: %s", err)
+	}
+	fl, err := m.Client.GetFileListContext(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	listed := make(map[string]bool, len(fl.Records))
+	for _, rec := range fl.Records {
+		if err := ctx.Err(); err != nil {
+			return res, m.finish(manifest, err)
+		}
+		listed[rec.File] = true
+		if err := checkName(rec.File); err != nil {
+			res.Failed[rec.File] = err
+			continue
+		}
+
+		entry := manifest.Files[rec.File]
+		if entry != nil && m.upToDate(entry) {
+			entry.Kind, entry.Name = rec.Kind, rec.Name
+			res.Unchanged = append(res.Unchanged, rec.File)
+			continue
+		}
+		entry, err := m.download(ctx, rec)
+		if err != nil {
+			res.Failed[rec.File] = err
+			continue
+		}
+		manifest.Files[rec.File] = entry
+		res.Downloaded = append(res.Downloaded, rec.File)
+	}
+
+	if m.Prune != PruneKeep {
+		for _, file := range sortedFiles(manifest) {
+			if listed[file] {
+				continue
+			}
+			if err := m.prune(file); err != nil {
+				res.Failed[file] = err
+				continue
+			}
+			delete(manifest.Files, file)
+			res.Pruned = append(res.Pruned, file)
+		}
+	}
+
+	var syncErr error
+	if len(res.Failed) > 0 {
+		syncErr = fmt.Errorf("%d files failed to sync", len(res.Failed))
+	}
+	return res, m.finish(manifest, syncErr)
+}
+
+// finish saves the manifest and returns err, or the error saving the manifest.
+func (m *Mirror) finish(manifest *Manifest, err error) error {
+	manifest.Updated = time.Now().UTC()
+	if saveErr := manifest.save(m.Dir); saveErr != nil && err == nil {
+		return fmt.Errorf("error saving manifest: %s", saveErr)
+	}
+	return err
+}
+
+func (m *Mirror) upToDate(entry *Entry) bool {
+	path := filepath.Join(m.Dir, entry.File)
+	fi, err := os.Stat(path)
+	if err != nil || fi.Size() != entry.Size {
+		return false
+	}
+	if !m.Verify {
+		return true
+	}
+	sum, err := fileSHA256(path)
+	return err == nil && sum == entry.SHA256
+}
+
+func (m *Mirror) download(ctx context.Context, rec expertview.Record) (*Entry, error) {
+	h := sha256.New()
+	var size int64
+	err := atomicfile.WriteFile(filepath.Join(m.Dir, rec.File), func(w io.Writer) error {
+		var err error
+		size, err = m.Client.GetFileTo(ctx, rec.File, io.MultiWriter(w, h))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{
+		Kind:   rec.Kind,
+		Name:   rec.Name,
+		File:   rec.File,
+		Size:   size,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+		Synced: time.Now().UTC(),
+	}, nil
+}
+
+func (m *Mirror) prune(file string) error {
+	path := filepath.Join(m.Dir, file)
+	if m.Prune == PruneDelete {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	archive := m.ArchiveDir
+	if archive == "" {
+		archive = DefaultArchiveDir
+	}
+	if !filepath.IsAbs(archive) {
+		archive = filepath.Join(m.Dir, archive)
+	}
+	if err := os.MkdirAll(archive, 0755); err != nil {
+		return err
+	}
+	err := os.Rename(path, filepath.Join(archive, file))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// checkName rejects file names that would be written outside the mirror directory, or over its manifest.
+func checkName(file string) error {
+	if file == "" || file == "." || file == ".." || filepath.Base(file) != file || file == ManifestName {
+		return errors.New("invalid file name")
+	}
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedFiles(manifest *Manifest) []string {
+	files := make([]string, 0, len(manifest.Files))
+	for file := range manifest.Files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
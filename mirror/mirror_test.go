@@ -0,0 +1,121 @@
+package mirror
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/expertviewtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	dcfRecord = expertview.Record{
+		Kind: expertview.DCFKind,
+		Name: "SQU-8000-TRKS-000000-131001CL.DCF",
+		File: "D9984527582012022715184747.dcf",
+	}
+	fwRecord = expertview.Record{
+		Kind: expertview.FirmwareKind,
+		Name: "8000-01V114R048.BIN",
+		File: "F1234.bin",
+	}
+)
+
+func TestMirror_Sync(t *testing.T) {
+	fake := expertviewtest.NewFake()
+	fake.AddFile(dcfRecord, []byte("hello"))
+	fake.AddFile(fwRecord, []byte("firmware"))
+	dir := t.TempDir()
+	m := &Mirror{Client: fake, Dir: dir, Prune: PruneArchive}
+
+	res, err := m.Sync(context.Background())
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []string{dcfRecord.File, fwRecord.File}, res.Downloaded)
+	b, err := ioutil.ReadFile(filepath.Join(dir, dcfRecord.File))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("hello"), b)
+	for _, name := range []string{dcfRecord.File, ManifestName} {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		require.Nil(t, err)
+		assert.Equal(t, os.FileMode(0644), fi.Mode().Perm(), name)
+	}
+
+	manifest, err := LoadManifest(dir)
+	require.Nil(t, err)
+	require.Len(t, manifest.Files, 2)
+	entry := manifest.Files[dcfRecord.File]
+	assert.Equal(t, expertview.DCFKind, entry.Kind)
+	assert.Equal(t, dcfRecord.Name, entry.Name)
+	assert.Equal(t, int64(5), entry.Size)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", entry.SHA256)
+
+	// second sync: nothing to download
+	res, err = m.Sync(context.Background())
+	require.Nil(t, err)
+	assert.Empty(t, res.Downloaded)
+	assert.ElementsMatch(t, []string{dcfRecord.File, fwRecord.File}, res.Unchanged)
+
+	// firmware removed from the list: archived
+	fake.SetFileList(expertview.FileList{Records: []expertview.Record{dcfRecord}})
+	res, err = m.Sync(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, []string{fwRecord.File}, res.Pruned)
+	_, err = os.Stat(filepath.Join(dir, fwRecord.File))
+	assert.True(t, os.IsNotExist(err))
+	b, err = ioutil.ReadFile(filepath.Join(dir, DefaultArchiveDir, fwRecord.File))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("firmware"), b)
+	manifest, err = LoadManifest(dir)
+	require.Nil(t, err)
+	assert.Len(t, manifest.Files, 1)
+}
+
+func TestMirror_SyncVerify(t *testing.T) {
+	fake := expertviewtest.NewFake()
+	fake.AddFile(dcfRecord, []byte("hello"))
+	dir := t.TempDir()
+	m := &Mirror{Client: fake, Dir: dir, Verify: true}
+
+	_, err := m.Sync(context.Background())
+	require.Nil(t, err)
+
+	// same size, different contents
+	err = ioutil.WriteFile(filepath.Join(dir, dcfRecord.File), []byte("HELLO"), 0644)
+	require.Nil(t, err)
+	res, err := m.Sync(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, []string{dcfRecord.File}, res.Downloaded)
+	b, err := ioutil.ReadFile(filepath.Join(dir, dcfRecord.File))
+	require.Nil(t, err)
+	assert.Equal(t, []byte("hello"), b)
+}
+
+func TestMirror_SyncFailures(t *testing.T) {
+	fake := expertviewtest.NewFake()
+	fake.AddFile(dcfRecord, []byte("hello"))
+	fake.AddFile(expertview.Record{Kind: expertview.DCFKind, Name: "EVIL.DCF", File: "../evil.dcf"}, []byte("evil"))
+	fake.SetFileList(expertview.FileList{Records: []expertview.Record{
+		dcfRecord,
+		{Kind: expertview.DCFKind, Name: "EVIL.DCF", File: "../evil.dcf"},
+		{Kind: expertview.FirmwareKind, Name: "MISSING.BIN", File: "missing.bin"},
+	}})
+	dir := t.TempDir()
+	m := &Mirror{Client: fake, Dir: dir, Prune: PruneDelete}
+
+	res, err := m.Sync(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{dcfRecord.File}, res.Downloaded)
+	assert.Len(t, res.Failed, 2)
+	assert.Equal(t, expertview.ErrNoSuchFile, res.Failed["missing.bin"])
+	_, err = os.Stat(filepath.Join(filepath.Dir(dir), "evil.dcf"))
+	assert.True(t, os.IsNotExist(err))
+
+	manifest, err := LoadManifest(dir)
+	require.Nil(t, err)
+	assert.Len(t, manifest.Files, 1)
+}
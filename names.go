@@ -0,0 +1,196 @@
+package expertview
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DCFName is the parsed display name of a DCF file, like "SQU-8000-OBD-BETA00-430NM-140901CL.DCF": a vendor
+// (SQU), a product number (8000, missing on some files), a vehicle or application (OBD), optional memory (256K),
+// firmware (FW49) and channel (000000, BETA00, FMS000...) tokens, other options (430NM) and the release date
+// (2014-09-01) with an optional suffix (CL).
+type DCFName struct {
+	// Raw is the name as given. It is the only field set when Valid is false.
+	Raw string
+	// Valid reports whether the name could be parsed.
+	Valid bool
+
+	Vendor   string
+	Product  string
+	Vehicle  string
+	Memory   string
+	Firmware int
+	Channel  string
+	Options  []string
+	Date     time.Time
+	Suffix   string
+}
+
+var (
+	dcfDateRe     = regexp.MustCompile(`^(\d{6})([A-Z]*)$`)
+	dcfProductRe  = regexp.MustCompile(`^\d{4}$`)
+	dcfMemoryRe   = regexp.MustCompile(`^\d+K$`)
+	dcfFirmwareRe = regexp.MustCompile(`^FW(\d+)$`)
+	dcfChannelRe  = regexp.MustCompile(`^(?:BETA\d{2}|[A-Z]{0,3}0{3,6})$`)
+)
+
+// ParseDCFName parses the display name of a DCF file (Record.Name or InstallationRecord.DCF). Names that do not
+// follow the usual pattern are returned with just Raw set.
+func ParseDCFName(name string) DCFName {
+	dn := DCFName{Raw: name}
+	upper := strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasSuffix(upper, ".DCF") {
+		return dn
+	}
+	tokens := strings.Split(strings.TrimSuffix(upper, ".DCF"), "-")
+	if len(tokens) < 3 {
+		return dn
+	}
+
+	m := dcfDateRe.FindStringSubmatch(tokens[len(tokens)-1])
+	if m == nil {
+		return dn
+	}
+	date, err := time.Parse("060102", m[1])
+	if err != nil {
+		return dn
+	}
+	dn.Date, dn.Suffix = date, m[2]
+	tokens = tokens[:len(tokens)-1]
+
+	dn.Vendor, tokens = tokens[0], tokens[1:]
+	if dcfProductRe.MatchString(tokens[0]) {
+		dn.Product, tokens = tokens[0], tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return DCFName{Raw: name}
+	}
+	dn.Vehicle, tokens = tokens[0], tokens[1:]
+	for _, tok := range tokens {
+		switch {
+		case dcfMemoryRe.MatchString(tok):
+			dn.Memory = tok
+		case dcfFirmwareRe.MatchString(tok):
+			dn.Firmware, _ = strconv.Atoi(tok[2:])
+		case dcfChannelRe.MatchString(tok):
+			dn.Channel = tok
+		default:
+			dn.Options = append(dn.Options, tok)
+		}
+	}
+	dn.Valid = true
+	return dn
+}
+
+// Family returns the vendor, product, vehicle, memory, channel and options of the name, like
+// "SQU-8000-TRKS-256K-FMS000": every variant for a different hardware is a family of its own, and only releases
+// of the same family are comparable. Beta channels count as the standard channel (000000), so a beta release is
+// compared with the stable ones of its variant. It is the raw name if the name is not valid.
+func (dn DCFName) Family() string {
+	if !dn.Valid {
+		return dn.Raw
+	}
+	parts := []string{dn.Vendor}
+	if dn.Product != "" {
+		parts = append(parts, dn.Product)
+	}
+	parts = append(parts, dn.Vehicle)
+	if dn.Memory != "" {
+		parts = append(parts, dn.Memory)
+	}
+	switch {
+	case dn.Beta():
+		parts = append(parts, "000000")
+	case dn.Channel != "":
+		parts = append(parts, dn.Channel)
+	}
+	parts = append(parts, dn.Options...)
+	return strings.Join(parts, "-")
+}
+
+// Beta reports whether the name is of a beta release (a BETA channel).
+func (dn DCFName) Beta() bool {
+	return strings.HasPrefix(dn.Channel, "BETA")
+}
+
+// Compare orders names by release date. Names released the same day are a tie. Invalid names sort before valid
+// ones.
+func (dn DCFName) Compare(o DCFName) int {
+	switch {
+	case dn.Valid != o.Valid:
+		if dn.Valid {
+			return 1
+		}
+		return -1
+	case dn.Date.Before(o.Date):
+		return -1
+	case dn.Date.After(o.Date):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// FirmwareName is the parsed display name of a firmware file, like "8000-01V114R048.BIN" (product 8000, hardware
+// 01, version 1.14, revision 48) or "8590H1V135.BIN" (product 8590, hardware H1, version 1.35, no revision).
+type FirmwareName struct {
+	// Raw is the name as given. It is the only field set when Valid is false.
+	Raw string
+	// Valid reports whether the name could be parsed.
+	Valid bool
+
+	Product  string
+	Hardware string
+	Major    int
+	Minor    int
+	Revision int
+}
+
+var firmwareRe = regexp.MustCompile(`^(\d{4})-?(\d{2}|H\d)V(\d)(\d{2})(?:R(\d{3}))?\.BIN$`)
+
+// ParseFirmwareName parses the display name of a firmware file (Record.Name or InstallationRecord.Firmware).
+// Names that do not follow the usual pattern are returned with just Raw set.
+func ParseFirmwareName(name string) FirmwareName {
+	fn := FirmwareName{Raw: name}
+	m := firmwareRe.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(name)))
+	if m == nil {
+		return fn
+	}
+	fn.Product, fn.Hardware = m[1], m[2]
+	fn.Major, _ = strconv.Atoi(m[3])
+	fn.Minor, _ = strconv.Atoi(m[4])
+	if m[5] != "" {
+		fn.Revision, _ = strconv.Atoi(m[5])
+	}
+	fn.Valid = true
+	return fn
+}
+
+// Family returns the product and hardware of the name, like "8000-01". It is the raw name if the name is not
+// valid.
+func (fn FirmwareName) Family() string {
+	if !fn.Valid {
+		return fn.Raw
+	}
+	return fn.Product + "-" + fn.Hardware
+}
+
+// Compare orders names by version and revision. Names of the same version and revision are a tie. Invalid names
+// sort before valid ones.
+func (fn FirmwareName) Compare(o FirmwareName) int {
+	switch {
+	case fn.Valid != o.Valid:
+		if fn.Valid {
+			return 1
+		}
+		return -1
+	case fn.Major != o.Major:
+		return cmpInt(fn.Major, o.Major)
+	case fn.Minor != o.Minor:
+		return cmpInt(fn.Minor, o.Minor)
+	default:
+		return cmpInt(fn.Revision, o.Revision)
+	}
+}
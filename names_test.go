@@ -0,0 +1,118 @@
+package expertview
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDCFName(t *testing.T) {
+	dn := ParseDCFName("SQU-8000-TRKS-000000-131001CL.DCF")
+	assert.Equal(t, DCFName{
+		Raw:     "SQU-8000-TRKS-000000-131001CL.DCF",
+		Valid:   true,
+		Vendor:  "SQU",
+		Product: "8000",
+		Vehicle: "TRKS",
+		Channel: "000000",
+		Date:    time.Date(2013, 10, 1, 0, 0, 0, 0, time.UTC),
+		Suffix:  "CL",
+	}, dn)
+	assert.Equal(t, "SQU-8000-TRKS-000000", dn.Family())
+
+	dn = ParseDCFName("SQU-8000-OBD-BETA00-430NM-140901CL.DCF")
+	assert.True(t, dn.Valid)
+	assert.Equal(t, "SQU-8000-OBD-000000-430NM", dn.Family())
+	assert.Equal(t, "BETA00", dn.Channel)
+	assert.True(t, dn.Beta())
+	assert.Equal(t, []string{"430NM"}, dn.Options)
+
+	dn = ParseDCFName("SQU-8000-TRKS-256K-FW49-FMS000-160621CL.DCF")
+	assert.True(t, dn.Valid)
+	assert.Equal(t, "256K", dn.Memory)
+	assert.Equal(t, 49, dn.Firmware)
+	assert.Equal(t, "FMS000", dn.Channel)
+	assert.False(t, dn.Beta())
+	assert.Equal(t, "SQU-8000-TRKS-256K-FMS000", dn.Family())
+
+	dn = ParseDCFName("SQU-FLX12-TDK-121113CL.DCF")
+	assert.True(t, dn.Valid)
+	assert.Equal(t, "", dn.Product)
+	assert.Equal(t, "SQU-FLX12-TDK", dn.Family())
+	assert.Equal(t, []string{"TDK"}, dn.Options)
+
+	dn = ParseDCFName("SQU-8000-RELAY-000000-121012.DCF")
+	assert.True(t, dn.Valid)
+	assert.Equal(t, "", dn.Suffix)
+
+	for _, name := range []string{"", "config.txt", "SQU-8000-TRKS-000000.DCF", "SQU-8000-TRKS-131399CL.DCF", "SQU-131001.DCF"} {
+		dn = ParseDCFName(name)
+		assert.Equal(t, DCFName{Raw: name}, dn, name)
+		assert.Equal(t, name, dn.Family())
+	}
+}
+
+func TestDCFName_Compare(t *testing.T) {
+	older := ParseDCFName("SQU-8000-OBD-000000-130802CL.DCF")
+	newer := ParseDCFName("SQU-8000-OBD-BETA00-140901CL.DCF")
+	assert.Equal(t, -1, older.Compare(newer))
+	assert.Equal(t, 1, newer.Compare(older))
+	assert.Equal(t, 0, older.Compare(older))
+	assert.Equal(t, -1, ParseDCFName("custom.dcf").Compare(older))
+
+	fms := ParseDCFName("SQU-8000-TRKS-256K-FW49-FMS000-160621CL.DCF")
+	tpt := ParseDCFName("SQU-8000-TRKS-256K-FW49-TPT000-160621CL.DCF")
+	assert.Equal(t, 0, fms.Compare(tpt))
+	assert.Equal(t, 0, tpt.Compare(fms))
+}
+
+func TestParseFirmwareName(t *testing.T) {
+	assert.Equal(t, FirmwareName{
+		Raw:      "8000-01V114R048.BIN",
+		Valid:    true,
+		Product:  "8000",
+		Hardware: "01",
+		Major:    1,
+		Minor:    14,
+		Revision: 48,
+	}, ParseFirmwareName("8000-01V114R048.BIN"))
+	assert.Equal(t, FirmwareName{
+		Raw:      "8590H1V135.BIN",
+		Valid:    true,
+		Product:  "8590",
+		Hardware: "H1",
+		Major:    1,
+		Minor:    35,
+	}, ParseFirmwareName("8590H1V135.BIN"))
+	assert.Equal(t, "8000-01", ParseFirmwareName("8000-01V114R048.BIN").Family())
+	assert.Equal(t, FirmwareName{Raw: "firmware.bin.old"}, ParseFirmwareName("firmware.bin.old"))
+}
+
+func TestFirmwareName_Compare(t *testing.T) {
+	v114 := ParseFirmwareName("8000-01V114R048.BIN")
+	v115 := ParseFirmwareName("8000-01V115R049.BIN")
+	v101 := ParseFirmwareName("8000-01V101R041.BIN")
+	assert.Equal(t, -1, v114.Compare(v115))
+	assert.Equal(t, 1, v114.Compare(v101))
+	assert.Equal(t, 0, v114.Compare(v114))
+	assert.Equal(t, 0, v114.Compare(ParseFirmwareName("800001V114R048.BIN")))
+}
+
+func TestParseNames_FileList(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/getFileListResponse.xml")
+	require.Nil(t, err)
+	fl, err := parseGetFileList(b)
+	require.Nil(t, err)
+
+	for _, rec := range fl.Records {
+		switch rec.Kind {
+		case DCFKind:
+			assert.True(t, ParseDCFName(rec.Name).Valid, rec.Name)
+		case FirmwareKind:
+			assert.True(t, ParseFirmwareName(rec.Name).Valid, rec.Name)
+		}
+	}
+}
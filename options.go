@@ -0,0 +1,80 @@
+package expertview
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"time"
+)
+
+// Option configures an *ExpertView created by NewExpertView.
+type Option func(ev *ExpertView)
+
+// WithHTTPClient makes every call go through the given client. TLS options are ignored when a client is given,
+// since its own transport is used as is.
+func WithHTTPClient(client *http.Client) Option {
+	return func(ev *ExpertView) {
+		ev.cli.Client = client
+	}
+}
+
+// WithTransport makes every call go through the given round tripper. TLS options are ignored when a transport is
+// given, and WithHTTPClient takes precedence over it.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(ev *ExpertView) {
+		ev.cli.Transport = rt
+	}
+}
+
+// WithTimeout bounds every call, including reading the response, to the given duration. Zero means no limit
+// other than the one of the call's context.
+func WithTimeout(d time.Duration) Option {
+	return func(ev *ExpertView) {
+		ev.cli.CallTimeout = d
+	}
+}
+
+// WithDialTimeout sets the connection timeout of the default transport (10 seconds if not set).
+func WithDialTimeout(d time.Duration) Option {
+	return func(ev *ExpertView) {
+		ev.cli.Timeout = d
+	}
+}
+
+// WithRootCAs sets the certificate authorities used to verify the server, instead of the system pool.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(ev *ExpertView) {
+		ev.cli.RootCAs = pool
+	}
+}
+
+// WithClientCertificates sets the certificates presented to the server when it asks for client authentication.
+func WithClientCertificates(certs ...tls.Certificate) Option {
+	return func(ev *ExpertView) {
+		ev.cli.Certificates = certs
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of the server certificate. Only meant for testing.
+func WithInsecureSkipVerify() Option {
+	return func(ev *ExpertView) {
+		ev.cli.InsecureSkipVerify = true
+	}
+}
+
+// WithIdlePool sizes the pool of keep-alive connections of the default transport: at most maxIdle idle
+// connections are kept, each for up to idleTimeout. Zero values keep the defaults (4 connections, 90 seconds).
+func WithIdlePool(maxIdle int, idleTimeout time.Duration) Option {
+	return func(ev *ExpertView) {
+		ev.cli.MaxIdleConns = maxIdle
+		ev.cli.IdleConnTimeout = idleTimeout
+	}
+}
+
+// WithRetryPolicy makes read operations retry transient failures as described by p (see DefaultRetryPolicy).
+// Operations that upload data, PostInstallRecords and PutLogBooks, are never retried.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(ev *ExpertView) {
+		ev.retry = p
+	}
+}
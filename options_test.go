@@ -0,0 +1,146 @@
+package expertview
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGetFileServer(delay time.Duration) *httptest.Server {
+	return httptest.NewTLSServer(getFileHandler(delay))
+}
+
+func getFileHandler(delay time.Duration) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+
+		f, err := os.Open("testdata/getFileResponse.xml")
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			panic(err)
+		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		rw.Write(b)
+	})
+}
+
+func TestNewExpertView_VerifiesTLSByDefault(t *testing.T) {
+	server := newGetFileServer(0)
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	})
+	require.Nil(t, err)
+	_, err = ev.GetFile("D9984527582012022715184747.dcf")
+	assert.NotNil(t, err)
+}
+
+func TestNewExpertView_WithRootCAs(t *testing.T) {
+	server := newGetFileServer(0)
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithRootCAs(pool))
+	require.Nil(t, err)
+	f, err := ev.GetFile("D9984527582012022715184747.dcf")
+	require.Nil(t, err)
+	assert.Equal(t, []byte("hello"), f)
+}
+
+func TestNewExpertView_WithInsecureSkipVerify(t *testing.T) {
+	server := newGetFileServer(0)
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithInsecureSkipVerify())
+	require.Nil(t, err)
+	f, err := ev.GetFile("D9984527582012022715184747.dcf")
+	require.Nil(t, err)
+	assert.Equal(t, []byte("hello"), f)
+}
+
+func TestNewExpertView_WithTransport(t *testing.T) {
+	server := newGetFileServer(0)
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithTransport(server.Client().Transport))
+	require.Nil(t, err)
+	f, err := ev.GetFile("D9984527582012022715184747.dcf")
+	require.Nil(t, err)
+	assert.Equal(t, []byte("hello"), f)
+}
+
+func TestNewExpertView_WithTimeout(t *testing.T) {
+	server := newGetFileServer(time.Second)
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()), WithTimeout(50*time.Millisecond))
+	require.Nil(t, err)
+	_, err = ev.GetFile("D9984527582012022715184747.dcf")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+}
+
+func TestExpertView_ReusesConnections(t *testing.T) {
+	server := httptest.NewUnstartedServer(getFileHandler(0))
+	var mu sync.Mutex
+	newConns := 0
+	server.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			newConns++
+			mu.Unlock()
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithRootCAs(pool), WithIdlePool(2, time.Minute))
+	require.Nil(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = ev.GetFile("D9984527582012022715184747.dcf")
+		require.Nil(t, err)
+	}
+	ev.CloseIdleConnections()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, newConns)
+}
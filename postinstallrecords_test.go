@@ -0,0 +1,122 @@
+package expertview
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var postRecordsRe = regexp.MustCompile("<records>([^<]*)</records>")
+
+var testInstallRecords = []InstallationRecord{
+	{
+		SerialNumber: "296930501",
+		ID:           "667769",
+		Telematic:    "Larix Ltda",
+		HardwareProf: "FLX12",
+		SoftwareProf: "FLEX-256-V2",
+		DCF:          "SQU-8000-TRKS-000000-131001CL.DCF",
+		Firmware:     "8000-01V114R048.BIN",
+		Key:          "293230583 FLX12 FLEX-256-V2 hjashj dshj dahy",
+		Username:     "asdf",
+	},
+}
+
+func TestExpertView_PostInstallRecords(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rb, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		ok := bytes.Contains(rb, []byte("<sq:postInstallRecords><login>demo</login><password>fe01ce2a7fbac8fafaed7c982a04e229</password><version>2.5.0</version><records>"))
+		if !ok {
+			t.Errorf("invalid soap call: %s", rb)
+			t.FailNow()
+		}
+		m := postRecordsRe.FindSubmatch(rb)
+		if m == nil {
+			t.Errorf("records not found: %s", rb)
+			t.FailNow()
+		}
+		records, err := base64.StdEncoding.DecodeString(string(m[1]))
+		if err != nil {
+			panic(err)
+		}
+		ok = bytes.Contains(records, []byte(`<INSTALLATIONS><RECORD SN="296930501"><ID>667769</ID><TELEMATIC>Larix Ltda</TELEMATIC><HARDWAREPROF>FLX12</HARDWAREPROF><SOFTWAREPROF>FLEX-256-V2</SOFTWAREPROF><DCF>SQU-8000-TRKS-000000-131001CL.DCF</DCF><FIRMWARE>8000-01V114R048.BIN</FIRMWARE><KEY>293230583 FLX12 FLEX-256-V2 hjashj dshj dahy</KEY><USERNAME>asdf</USERNAME></RECORD></INSTALLATIONS>`))
+		if !ok {
+			t.Errorf("invalid records: %s", records)
+			t.FailNow()
+		}
+
+		f, err := os.Open("testdata/postInstallRecordsResponse.xml")
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			panic(err)
+		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		rw.Write(b)
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	err = ev.PostInstallRecords(testInstallRecords)
+	assert.Nil(t, err)
+}
+
+func TestExpertView_PostInstallRecordsAuthEx(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rb, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		ok := bytes.Contains(rb, []byte("<sq:postInstallRecords><login>demo</login><password>fe01ce2a7fbac8fafaed7c982a04e229</password><version>2.5.0</version><records>"))
+		if !ok {
+			t.Errorf("invalid soap call: %s", rb)
+			t.FailNow()
+		}
+
+		f, err := os.Open("testdata/postInstallRecordsResponseAuthEx.xml")
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			panic(err)
+		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		rw.Write(b)
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	err = ev.PostInstallRecords(testInstallRecords)
+	assert.True(t, errors.Is(err, ErrAuthentication), "unexpected error: %v", err)
+}
+
+func TestInstallRecordsPayload(t *testing.T) {
+	payload, err := InstallRecordsPayload(testInstallRecords)
+	require.Nil(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<INSTALLATIONS><RECORD SN="296930501"><ID>667769</ID><TELEMATIC>Larix Ltda</TELEMATIC><HARDWAREPROF>FLX12</HARDWAREPROF><SOFTWAREPROF>FLEX-256-V2</SOFTWAREPROF><DCF>SQU-8000-TRKS-000000-131001CL.DCF</DCF><FIRMWARE>8000-01V114R048.BIN</FIRMWARE><KEY>293230583 FLX12 FLEX-256-V2 hjashj dshj dahy</KEY><USERNAME>asdf</USERNAME></RECORD></INSTALLATIONS>`, string(payload))
+}
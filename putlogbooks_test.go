@@ -0,0 +1,165 @@
+package expertview
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var putLogBooksRe = regexp.MustCompile("<logbooks>([^<]*)</logbooks>")
+
+func testLogBooks() []LogBook {
+	start := time.Date(2016, 8, 25, 8, 0, 0, 0, time.UTC)
+	return []LogBook{
+		{
+			SerialNumber: "296930501",
+			Driver:       "J. Perez",
+			Vehicle:      "AB-1234",
+			Entries: []LogBookEntry{
+				{
+					Start:         start,
+					End:           start.Add(2 * time.Hour),
+					Activity:      DrivingActivity,
+					StartOdometer: 120500,
+					EndOdometer:   120640.5,
+				},
+				{
+					Start:    start.Add(2 * time.Hour),
+					End:      start.Add(150 * time.Minute),
+					Activity: RestActivity,
+				},
+			},
+		},
+	}
+}
+
+func TestExpertView_PutLogBooks(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rb, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		ok := bytes.Contains(rb, []byte("<sq:putLogBooks><login>demo</login><password>fe01ce2a7fbac8fafaed7c982a04e229</password><version>2.5.0</version><logbooks>"))
+		if !ok {
+			t.Errorf("invalid soap call: %s", rb)
+			t.FailNow()
+		}
+		m := putLogBooksRe.FindSubmatch(rb)
+		if m == nil {
+			t.Errorf("logbooks not found: %s", rb)
+			t.FailNow()
+		}
+		logBooks, err := base64.StdEncoding.DecodeString(string(m[1]))
+		if err != nil {
+			panic(err)
+		}
+		ok = bytes.Contains(logBooks, []byte(`<LOGBOOKS><LOGBOOK SN="296930501"><DRIVER>J. Perez</DRIVER><VEHICLE>AB-1234</VEHICLE><ENTRIES><ENTRY ACTIVITY="DRIVING"><START>2016/08/25 08:00:00</START><END>2016/08/25 10:00:00</END><STARTODOMETER>120500</STARTODOMETER><ENDODOMETER>120640.5</ENDODOMETER></ENTRY><ENTRY ACTIVITY="REST"><START>2016/08/25 10:00:00</START><END>2016/08/25 10:30:00</END></ENTRY></ENTRIES></LOGBOOK></LOGBOOKS>`))
+		if !ok {
+			t.Errorf("invalid logbooks: %s", logBooks)
+			t.FailNow()
+		}
+
+		f, err := os.Open("testdata/putLogBooksResponse.xml")
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			panic(err)
+		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		rw.Write(b)
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	results, err := ev.PutLogBooks(testLogBooks())
+	require.Nil(t, err)
+
+	assert.Equal(t, []LogBookResult{
+		{SerialNumber: "296930501", Accepted: 2, Rejected: 0, Message: "OK"},
+	}, results)
+}
+
+func TestExpertView_PutLogBooksAuthEx(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		f, err := os.Open("testdata/putLogBooksResponseAuthEx.xml")
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			panic(err)
+		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		rw.Write(b)
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	_, err = ev.PutLogBooks(testLogBooks())
+	assert.True(t, errors.Is(err, ErrAuthentication), "unexpected error: %v", err)
+}
+
+func TestLogBook_Validate(t *testing.T) {
+	lb := testLogBooks()[0]
+	assert.Nil(t, lb.Validate())
+
+	lb.SerialNumber = ""
+	assert.NotNil(t, lb.Validate())
+
+	lb = testLogBooks()[0]
+	lb.Entries[0].Activity = "SLEEPING"
+	assert.NotNil(t, lb.Validate())
+
+	lb = testLogBooks()[0]
+	lb.Entries[0].EndOdometer = 100
+	assert.NotNil(t, lb.Validate())
+
+	lb = testLogBooks()[0]
+	lb.Entries[1].Start = lb.Entries[0].Start
+	assert.NotNil(t, lb.Validate())
+
+	lb = testLogBooks()[0]
+	lb.Entries[0], lb.Entries[1] = lb.Entries[1], lb.Entries[0]
+	assert.Nil(t, lb.Validate())
+	lb.Entries[0].Start = lb.Entries[1].Start.Add(time.Hour)
+	assert.EqualError(t, lb.Validate(), "entry 0: overlaps entry 1")
+}
+
+func TestExpertView_PutLogBooksInvalid(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected soap call")
+	}))
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()))
+	require.Nil(t, err)
+	lbs := testLogBooks()
+	lbs[0].Entries[0].End = lbs[0].Entries[0].Start.Add(-time.Minute)
+	_, err = ev.PutLogBooks(lbs)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,99 @@
+package expertview
+
+import "sort"
+
+// Family returns the release family of the record, parsed from its name: like "SQU-8000-OBD-000000" for DCFs (see
+// DCFName.Family) or "8000-01" for firmware (see FirmwareName.Family). Records with a name that cannot be parsed
+// are a family on their own, named after the record.
+func (r Record) Family() string {
+	switch r.Kind {
+	case DCFKind:
+		return ParseDCFName(r.Name).Family()
+	case FirmwareKind:
+		return ParseFirmwareName(r.Name).Family()
+	default:
+		return r.Name
+	}
+}
+
+// CompareRelease orders two records of the same kind by release: DCFs by the date in their names, firmware by
+// the version in their names.
+func (r Record) CompareRelease(o Record) int {
+	if r.Kind == FirmwareKind && o.Kind == FirmwareKind {
+		return ParseFirmwareName(r.Name).Compare(ParseFirmwareName(o.Name))
+	}
+	return ParseDCFName(r.Name).Compare(ParseDCFName(o.Name))
+}
+
+func (r Record) beta() bool {
+	return r.Kind == DCFKind && ParseDCFName(r.Name).Beta()
+}
+
+// Release is the newest record of a family.
+type Release struct {
+	Kind   RecordKind
+	Family string
+	Record Record
+	// Older holds the other records of the family, newest first.
+	Older []Record
+}
+
+// Families groups the records by kind and family, ordering every family from the newest to the oldest release.
+// The families are returned ordered by kind and name.
+func (fl FileList) Families() []Release {
+	return fl.families(true)
+}
+
+// LatestReleases returns the newest record of every family, ordered by kind and family name. Beta DCFs (like
+// "SQU-8000-OBD-BETA00-140901CL.DCF") are included; see LatestStableReleases.
+func (fl FileList) LatestReleases() []Record {
+	return latest(fl.families(true))
+}
+
+// LatestStableReleases is like LatestReleases, but beta DCFs are left out.
+func (fl FileList) LatestStableReleases() []Record {
+	return latest(fl.families(false))
+}
+
+func latest(releases []Release) []Record {
+	records := make([]Record, 0, len(releases))
+	for _, rel := range releases {
+		records = append(records, rel.Record)
+	}
+	return records
+}
+
+func (fl FileList) families(betas bool) []Release {
+	type key struct {
+		kind   RecordKind
+		family string
+	}
+	groups := make(map[key][]Record)
+	for _, rec := range fl.Records {
+		if !betas && rec.beta() {
+			continue
+		}
+		k := key{rec.Kind, rec.Family()}
+		groups[k] = append(groups[k], rec)
+	}
+
+	releases := make([]Release, 0, len(groups))
+	for k, records := range groups {
+		sort.SliceStable(records, func(i, j int) bool {
+			return records[i].CompareRelease(records[j]) > 0
+		})
+		releases = append(releases, Release{
+			Kind:   k.kind,
+			Family: k.family,
+			Record: records[0],
+			Older:  records[1:],
+		})
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		if releases[i].Kind != releases[j].Kind {
+			return releases[i].Kind < releases[j].Kind
+		}
+		return releases[i].Family < releases[j].Family
+	})
+	return releases
+}
@@ -0,0 +1,103 @@
+package expertview
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFileList(t *testing.T) FileList {
+	b, err := ioutil.ReadFile("testdata/getFileListResponse.xml")
+	require.Nil(t, err)
+	fl, err := parseGetFileList(b)
+	require.Nil(t, err)
+	return fl
+}
+
+func findRelease(records []Record, kind RecordKind, family string) (Record, bool) {
+	for _, rec := range records {
+		if rec.Kind == kind && rec.Family() == family {
+			return rec, true
+		}
+	}
+	return Record{}, false
+}
+
+func TestFileList_LatestReleases(t *testing.T) {
+	latest := testFileList(t).LatestReleases()
+
+	rec, ok := findRelease(latest, DCFKind, "SQU-8000-OBD-000000")
+	require.True(t, ok)
+	assert.Equal(t, "SQU-8000-OBD-BETA00-140901CL.DCF", rec.Name)
+
+	rec, ok = findRelease(latest, DCFKind, "SQU-8000-OBD-000000-430NM")
+	require.True(t, ok)
+	assert.Equal(t, "SQU-8000-OBD-BETA00-430NM-140901CL.DCF", rec.Name)
+
+	rec, ok = findRelease(latest, FirmwareKind, "8000-01")
+	require.True(t, ok)
+	assert.Equal(t, "8000-01V115R049.BIN", rec.Name)
+
+	for i := 1; i < len(latest); i++ {
+		prev, cur := latest[i-1], latest[i]
+		assert.True(t, prev.Kind < cur.Kind || prev.Kind == cur.Kind && prev.Family() < cur.Family())
+	}
+}
+
+func TestFileList_LatestStableReleases(t *testing.T) {
+	latest := testFileList(t).LatestStableReleases()
+
+	rec, ok := findRelease(latest, DCFKind, "SQU-8000-OBD-000000")
+	require.True(t, ok)
+	assert.Equal(t, "SQU-8000-OBD-000000-130802CL.DCF", rec.Name)
+
+	_, ok = findRelease(latest, DCFKind, "SQU-8000-OBD-000000-430NM")
+	assert.False(t, ok)
+
+	_, ok = findRelease(latest, DCFKind, "INT-8000-REN")
+	assert.False(t, ok)
+}
+
+func TestFileList_Families(t *testing.T) {
+	fl := FileList{Records: []Record{
+		{Kind: FirmwareKind, Name: "8000-01V101R041.BIN", File: "a"},
+		{Kind: FirmwareKind, Name: "8000-01V115R049.BIN", File: "b"},
+		{Kind: FirmwareKind, Name: "8000-01V114R048.BIN", File: "c"},
+		{Kind: DCFKind, Name: "custom.dcf", File: "d"},
+	}}
+	families := fl.Families()
+
+	require.Len(t, families, 2)
+	assert.Equal(t, Release{
+		Kind:   DCFKind,
+		Family: "custom.dcf",
+		Record: Record{Kind: DCFKind, Name: "custom.dcf", File: "d"},
+		Older:  []Record{},
+	}, families[0])
+	assert.Equal(t, "8000-01", families[1].Family)
+	assert.Equal(t, "b", families[1].Record.File)
+	assert.Equal(t, []Record{
+		{Kind: FirmwareKind, Name: "8000-01V114R048.BIN", File: "c"},
+		{Kind: FirmwareKind, Name: "8000-01V101R041.BIN", File: "a"},
+	}, families[1].Older)
+}
+
+func TestFileList_LatestStableReleases_Variants(t *testing.T) {
+	latest := testFileList(t).LatestStableReleases()
+
+	for _, name := range []string{
+		"SQU-8000-TRKS-256K-FW49-000000-160621CL.DCF",
+		"SQU-8000-TRKS-256K-FW49-FMS000-160621CL.DCF",
+		"SQU-8000-TRKS-256K-FW49-TPT000-160621CL.DCF",
+		"SQU-8000-MBS-TPT000-BT+DUP-150709CL.DCF",
+	} {
+		rec := Record{Kind: DCFKind, Name: name}
+		got, ok := findRelease(latest, DCFKind, rec.Family())
+		require.True(t, ok, name)
+		assert.Equal(t, name, got.Name)
+	}
+	_, ok := findRelease(latest, DCFKind, "SQU-8000-TRKS")
+	assert.False(t, ok)
+}
@@ -0,0 +1,124 @@
+package expertview
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how read operations (GetFileList, GetFile, GetFileTo, GetInstallationRecords and
+// GetVersion) are retried after a transient failure. The zero value disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, the first one included. Values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry; every following wait is multiplied by Multiplier (2 if
+	// not set) up to MaxBackoff (no limit if not set).
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction, between 0 and 1, of every wait that is randomized. With 0.5 a wait of 1s becomes a
+	// random duration between 0.5s and 1s.
+	Jitter float64
+	// Retryable reports whether a failed attempt should be retried. IsRetryable is used if nil.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy makes up to 4 attempts, waiting about 0.5s, 1s and 2s between them.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.5,
+}
+
+// backoff returns the wait before the given retry (1 for the first one).
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	mult := p.Multiplier
+	if mult <= 0 {
+		mult = 2
+	}
+	d := float64(p.InitialBackoff) * math.Pow(mult, float64(retry-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		d -= d * jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// IsRetryable reports whether err is a transient failure: a network error, a timeout, a 5xx or 429 HTTP response,
+// or an UnexpectedException fault. ErrAuthentication, ErrNoSuchFile and ErrFirmwareNotSelectable are never
+// retryable, and neither is the cancellation of a call context.
+func IsRetryable(err error) bool {
+	var he *HTTPError
+	var ne net.Error
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrAuthentication), errors.Is(err, ErrNoSuchFile), errors.Is(err, ErrFirmwareNotSelectable):
+		return false
+	case errors.Is(err, ErrUnexpected):
+		return true
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.As(err, &he):
+		return he.StatusCode >= 500 || he.StatusCode == http.StatusTooManyRequests
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.As(err, &ne) && ne.Timeout():
+		return true
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.EPIPE):
+		return true
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return true
+	}
+	return false
+}
+
+// noRetryError makes withRetry give up on an otherwise retryable error.
+type noRetryError struct {
+	err error
+}
+
+func (e *noRetryError) Error() string {
+	return e.err.Error()
+}
+
+// withRetry runs op until it succeeds, fails with an error that is not retryable, the retry policy runs out of
+// attempts or ctx is done. It returns the error of the last attempt.
+func (ev *ExpertView) withRetry(ctx context.Context, op func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := op()
+		var nre *noRetryError
+		if errors.As(err, &nre) {
+			return nre.err
+		}
+		if err == nil || attempt >= ev.retry.MaxAttempts || ctx.Err() != nil || !ev.retry.retryable(err) {
+			return err
+		}
+
+		t := time.NewTimer(ev.retry.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+	}
+}
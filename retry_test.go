@@ -0,0 +1,158 @@
+package expertview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+	Jitter:         0.5,
+}
+
+// newFlakyServer answers with the given fixtures in turn, repeating the last one. An empty fixture makes it
+// answer with a 503 Service Unavailable.
+func newFlakyServer(hits *int32, fixtures ...string) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		i := int(atomic.AddInt32(hits, 1)) - 1
+		if i >= len(fixtures) {
+			i = len(fixtures) - 1
+		}
+		if fixtures[i] == "" {
+			rw.Header().Set("Content-Type", "text/html")
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		f, err := os.Open(fixtures[i])
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			panic(err)
+		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		rw.Write(b)
+	}))
+}
+
+func TestExpertView_RetryServiceUnavailable(t *testing.T) {
+	var hits int32
+	server := newFlakyServer(&hits, "", "", "testdata/getFileListResponse.xml")
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()), WithRetryPolicy(testRetryPolicy))
+	require.Nil(t, err)
+	fl, err := ev.GetFileList()
+	require.Nil(t, err)
+
+	assert.Len(t, fl.Records, 56)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+}
+
+func TestExpertView_RetryMaxAttempts(t *testing.T) {
+	var hits int32
+	server := newFlakyServer(&hits, "testdata/getFileListResponseUnexpectedEx.xml")
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()), WithRetryPolicy(testRetryPolicy))
+	require.Nil(t, err)
+	_, err = ev.GetFileList()
+
+	assert.True(t, errors.Is(err, ErrUnexpected), "unexpected error: %v", err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+}
+
+func TestExpertView_RetryAuthEx(t *testing.T) {
+	var hits int32
+	server := newFlakyServer(&hits, "testdata/getFileListResponseAuthEx.xml", "testdata/getFileListResponse.xml")
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()), WithRetryPolicy(testRetryPolicy))
+	require.Nil(t, err)
+	_, err = ev.GetFileList()
+
+	assert.True(t, errors.Is(err, ErrAuthentication), "unexpected error: %v", err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestExpertView_RetryNotOnPost(t *testing.T) {
+	var hits int32
+	server := newFlakyServer(&hits, "", "testdata/postInstallRecordsResponse.xml")
+	defer server.Close()
+
+	ev, err := NewExpertView(server.URL, DefaultVersion, Credentials{
+		Login:    "demo",
+		Password: "demo",
+	}, WithHTTPClient(server.Client()), WithRetryPolicy(testRetryPolicy))
+	require.Nil(t, err)
+	err = ev.PostInstallRecords(testInstallRecords)
+
+	var he *HTTPError
+	assert.True(t, errors.As(err, &he), "unexpected error: %v", err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{nil, false},
+		{ErrAuthentication, false},
+		{&FaultError{err: ErrNoSuchFile}, false},
+		{&FaultError{err: ErrFirmwareNotSelectable}, false},
+		{&FaultError{err: ErrUnexpected}, true},
+		{&FaultError{}, false},
+		{&HTTPError{StatusCode: http.StatusBadGateway}, true},
+		{&HTTPError{StatusCode: http.StatusTooManyRequests}, true},
+		{&HTTPError{StatusCode: http.StatusNotFound}, false},
+		{fmt.Errorf("dial: %w", context.DeadlineExceeded), true},
+		{context.Canceled, false},
+		{errors.New("boom"), false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.retryable, IsRetryable(test.err), "%v", test.err)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, p.backoff(4))
+	assert.Equal(t, time.Second, p.backoff(5))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.backoff(2)
+		assert.True(t, d > 100*time.Millisecond && d <= 200*time.Millisecond, "backoff %s", d)
+	}
+}
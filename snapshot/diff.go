@@ -0,0 +1,73 @@
+package snapshot
+
+import (
+	"time"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/export"
+)
+
+// FieldChange is a field of an installation record that changed between two snapshots.
+type FieldChange struct {
+	Field export.Field `json:"field"`
+	From  string       `json:"from"`
+	To    string       `json:"to"`
+}
+
+// Change is a unit whose installation record changed between two snapshots.
+type Change struct {
+	SerialNumber string        `json:"serialNumber"`
+	Fields       []FieldChange `json:"fields"`
+}
+
+// Diff is what changed in the fleet between two snapshots. Units are ordered by serial number.
+type Diff struct {
+	From    time.Time                       `json:"from"`
+	To      time.Time                       `json:"to"`
+	Added   []expertview.InstallationRecord `json:"added"`
+	Removed []expertview.InstallationRecord `json:"removed"`
+	Changed []Change                        `json:"changed"`
+}
+
+// Empty reports whether nothing changed.
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Compare returns the units added, removed and changed from the snapshot from to the snapshot to.
+func Compare(from, to *Snapshot) *Diff {
+	d := &Diff{
+		From:    from.Taken,
+		To:      to.Taken,
+		Added:   []expertview.InstallationRecord{},
+		Removed: []expertview.InstallationRecord{},
+		Changed: []Change{},
+	}
+	for _, sn := range from.SerialNumbers() {
+		if _, ok := to.Records[sn]; !ok {
+			d.Removed = append(d.Removed, from.Records[sn])
+		}
+	}
+	for _, sn := range to.SerialNumbers() {
+		ir := to.Records[sn]
+		old, ok := from.Records[sn]
+		if !ok {
+			d.Added = append(d.Added, ir)
+			continue
+		}
+		if fields := compareRecords(&old, &ir); len(fields) > 0 {
+			d.Changed = append(d.Changed, Change{SerialNumber: sn, Fields: fields})
+		}
+	}
+	return d
+}
+
+func compareRecords(from, to *expertview.InstallationRecord) []FieldChange {
+	var fields []FieldChange
+	for _, f := range export.Fields {
+		if v, w := f.Value(from), f.Value(to); v != w {
+			fields = append(fields, FieldChange{Field: f, From: v, To: w})
+		}
+	}
+	return fields
+}
@@ -0,0 +1,101 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+)
+
+// EventKind tells what happened to a unit in an Event.
+type EventKind string
+
+const (
+	Added   EventKind = "added"
+	Changed EventKind = "changed"
+	Removed EventKind = "removed"
+)
+
+// Event is a change in the installation record of a unit, as seen by the snapshots. The change happened after
+// Previous, the last snapshot with the former state (nil if there is no older snapshot), and no later than Time,
+// the first snapshot with the new state.
+type Event struct {
+	Kind     EventKind  `json:"kind"`
+	Time     time.Time  `json:"time"`
+	Previous *time.Time `json:"previous,omitempty"`
+	// Record is the installation record from Time on. It is zero for Removed events.
+	Record expertview.InstallationRecord `json:"record"`
+	// Fields are the fields that changed, for Changed events.
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// History is the change timeline of a unit, oldest event first.
+type History struct {
+	SerialNumber string  `json:"serialNumber"`
+	Events       []Event `json:"events"`
+}
+
+// At returns the installation record of the unit at time t, according to the newest snapshot taken at or before
+// t. It reports false if the unit was not in that snapshot, or t is before the first event.
+func (h *History) At(t time.Time) (expertview.InstallationRecord, bool) {
+	var ir expertview.InstallationRecord
+	ok := false
+	for _, ev := range h.Events {
+		if ev.Time.After(t) {
+			break
+		}
+		ir, ok = ev.Record, ev.Kind != Removed
+	}
+	return ir, ok
+}
+
+// History returns the change timeline of the unit with the given serial number. A unit never seen has no events.
+//
+// Timelines are kept in a per-unit index, brought up to date first: only the snapshots saved since the last
+// call are read, along with the newest one already indexed. Saving a snapshot older than the newest indexed one,
+// or replacing it with one taken within the same second, makes the next call rebuild the index from every
+// snapshot.
+func (st *Store) History(serialNumber string) (*History, error) {
+	if err := st.updateIndex(); err != nil {
+		return nil, err
+	}
+	return st.loadHistory(serialNumber)
+}
+
+// Recorder saves a snapshot of the installation records to a store periodically, building the history of every
+// unit.
+type Recorder struct {
+	Client   expertview.Client
+	Store    *Store
+	Interval time.Duration
+	// OnError, if set, is called with the errors of the snapshots that failed. Recording goes on.
+	OnError func(err error)
+}
+
+// Run takes a snapshot right away and then every Interval, until ctx is done. Interval must be positive.
+func (r *Recorder) Run(ctx context.Context) error {
+	if r.Interval <= 0 {
+		return fmt.Errorf("invalid recorder interval %s: must be positive", r.Interval)
+	}
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		if err := r.record(ctx); err != nil && ctx.Err() == nil && r.OnError != nil {
+			r.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Recorder) record(ctx context.Context) error {
+	s, err := Take(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+	return r.Store.Save(s)
+}
@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/expertviewtest"
+	"github.com/larixsource/go-expertview/export"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_History(t *testing.T) {
+	st, err := NewStore(t.TempDir())
+	require.Nil(t, err)
+
+	updated := unit1
+	updated.Firmware = "8000-01V115R049.BIN"
+	day0 := day1.Add(-24 * time.Hour)
+	day3 := day2.Add(24 * time.Hour)
+	day4 := day3.Add(24 * time.Hour)
+	for _, s := range []*Snapshot{
+		New([]expertview.InstallationRecord{unit2}, day0),
+		New([]expertview.InstallationRecord{unit1, unit2}, day1),
+		New([]expertview.InstallationRecord{unit1}, day2),
+		New([]expertview.InstallationRecord{updated}, day3),
+		New([]expertview.InstallationRecord{unit2}, day4),
+	} {
+		require.Nil(t, st.Save(s))
+	}
+
+	h, err := st.History("296930501")
+	require.Nil(t, err)
+	assert.Equal(t, &History{
+		SerialNumber: "296930501",
+		Events: []Event{
+			{Kind: Added, Time: day1, Previous: &day0, Record: unit1},
+			{
+				Kind:     Changed,
+				Time:     day3,
+				Previous: &day2,
+				Record:   updated,
+				Fields:   []FieldChange{{Field: export.Firmware, From: "8000-01V114R048.BIN", To: "8000-01V115R049.BIN"}},
+			},
+			{Kind: Removed, Time: day4, Previous: &day3},
+		},
+	}, h)
+
+	_, ok := h.At(day0)
+	assert.False(t, ok)
+	ir, ok := h.At(day2.Add(time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, "8000-01V114R048.BIN", ir.Firmware)
+	ir, ok = h.At(day3)
+	assert.True(t, ok)
+	assert.Equal(t, "8000-01V115R049.BIN", ir.Firmware)
+	_, ok = h.At(day4.Add(time.Hour))
+	assert.False(t, ok)
+
+	h, err = st.History("296930502")
+	require.Nil(t, err)
+	require.NotEmpty(t, h.Events)
+	assert.Nil(t, h.Events[0].Previous)
+	b, err := json.Marshal(h.Events[0])
+	require.Nil(t, err)
+	assert.NotContains(t, string(b), "previous")
+
+	h, err = st.History("0")
+	require.Nil(t, err)
+	assert.Empty(t, h.Events)
+}
+
+func TestStore_HistoryIndex(t *testing.T) {
+	st, err := NewStore(t.TempDir())
+	require.Nil(t, err)
+
+	updated := unit1
+	updated.Firmware = "8000-01V115R049.BIN"
+	day3 := day2.Add(24 * time.Hour)
+	require.Nil(t, st.Save(New([]expertview.InstallationRecord{unit1}, day1)))
+	h, err := st.History("296930501")
+	require.Nil(t, err)
+	require.Len(t, h.Events, 1)
+
+	// only new snapshots and the newest indexed one are read
+	require.Nil(t, st.Save(New([]expertview.InstallationRecord{unit1, unit2}, day2)))
+	h, err = st.History("296930502")
+	require.Nil(t, err)
+	assert.Equal(t, []Event{{Kind: Added, Time: day2, Previous: &day1, Record: unit2}}, h.Events)
+	require.Nil(t, ioutil.WriteFile(st.path(day1), []byte("corrupt"), 0644))
+	require.Nil(t, st.Save(New([]expertview.InstallationRecord{updated, unit2}, day3)))
+	h, err = st.History("296930501")
+	require.Nil(t, err)
+	require.Len(t, h.Events, 2)
+	assert.Equal(t, Changed, h.Events[1].Kind)
+	assert.Equal(t, day3, h.Events[1].Time)
+
+	// replacing the newest indexed snapshot rebuilds the index
+	require.Nil(t, st.Save(New([]expertview.InstallationRecord{unit1}, day1)))
+	require.Nil(t, st.Save(New([]expertview.InstallationRecord{updated}, day3)))
+	h, err = st.History("296930502")
+	require.Nil(t, err)
+	require.Len(t, h.Events, 2)
+	assert.Equal(t, Removed, h.Events[1].Kind)
+	assert.Equal(t, day3, h.Events[1].Time)
+	require.Nil(t, st.Save(New([]expertview.InstallationRecord{updated, unit2}, day3)))
+
+	// so does an older snapshot
+	day0 := day1.Add(-24 * time.Hour)
+	require.Nil(t, st.Save(New([]expertview.InstallationRecord{unit2}, day0)))
+	h, err = st.History("296930502")
+	require.Nil(t, err)
+	assert.Equal(t, []Event{
+		{Kind: Added, Time: day0, Record: unit2},
+		{Kind: Removed, Time: day1, Previous: &day0},
+		{Kind: Added, Time: day2, Previous: &day1, Record: unit2},
+	}, h.Events)
+}
+
+func TestRecorder(t *testing.T) {
+	fake := expertviewtest.NewFake()
+	fake.SetInstallationRecords([]expertview.InstallationRecord{unit1})
+	st, err := NewStore(filepath.Join(t.TempDir(), "snapshots"))
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r := &Recorder{Client: fake, Store: st, Interval: 10 * time.Millisecond}
+	assert.Equal(t, context.DeadlineExceeded, r.Run(ctx))
+	s, err := st.Latest()
+	require.Nil(t, err)
+	assert.Equal(t, unit1, s.Records["296930501"])
+
+	fake.SetError(expertviewtest.GetInstallationRecords, expertview.ErrUnexpected)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	var errs []error
+	r.OnError = func(err error) {
+		errs = append(errs, err)
+		cancel()
+	}
+	assert.Equal(t, context.Canceled, r.Run(ctx))
+	require.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], expertview.ErrUnexpected))
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		r = &Recorder{Client: fake, Store: st, Interval: interval}
+		assert.EqualError(t, r.Run(context.Background()), "invalid recorder interval "+interval.String()+": must be positive")
+	}
+}
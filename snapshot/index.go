@@ -0,0 +1,148 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/larixsource/go-expertview/internal/atomicfile"
+)
+
+// indexState tells how many snapshots, up to which one, are in the index. Hash is the SHA-256 of the file of the
+// last one, which a snapshot taken within the same second replaces.
+type indexState struct {
+	Snapshots int       `json:"snapshots"`
+	Last      time.Time `json:"last"`
+	Hash      string    `json:"hash"`
+}
+
+func (st *Store) indexPath(name string) string {
+	return filepath.Join(st.Dir, "index", name)
+}
+
+func (st *Store) unitPath(serialNumber string) string {
+	return st.indexPath(filepath.Join("units", url.PathEscape(serialNumber)+".json"))
+}
+
+func readJSON(path string, v interface{}) (bool, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(b, v)
+}
+
+func writeJSON(path string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(path, b)
+}
+
+// updateIndex adds the snapshots saved since the last update to the index, or rebuilds it if older snapshots
+// were saved meanwhile or the last indexed one was replaced.
+func (st *Store) updateIndex() error {
+	times, err := st.List()
+	if err != nil {
+		return err
+	}
+	var state indexState
+	if _, err = readJSON(st.indexPath("state.json"), &state); err != nil {
+		return err
+	}
+	var prev *Snapshot
+	if state.Snapshots > 0 && state.Snapshots <= len(times) && times[state.Snapshots-1].Equal(state.Last) {
+		s, hash, err := st.load(state.Last)
+		if err != nil {
+			return err
+		}
+		if hash == state.Hash {
+			prev = s
+		}
+	}
+	if prev == nil {
+		state = indexState{}
+		if err = os.RemoveAll(st.indexPath("")); err != nil {
+			return err
+		}
+	}
+	if state.Snapshots == len(times) {
+		return nil
+	}
+	if err = os.MkdirAll(st.indexPath("units"), 0755); err != nil {
+		return err
+	}
+
+	for _, t := range times[state.Snapshots:] {
+		s, hash, err := st.load(t)
+		if err != nil {
+			return err
+		}
+		if err = st.indexSnapshot(prev, s); err != nil {
+			return err
+		}
+		// saved after every snapshot, so an interrupted update goes on from there
+		state = indexState{Snapshots: state.Snapshots + 1, Last: t, Hash: hash}
+		if err = writeJSON(st.indexPath("state.json"), state); err != nil {
+			return err
+		}
+		prev = s
+	}
+	return nil
+}
+
+// indexSnapshot adds the changes from prev, nil for the first snapshot, to s to the timeline of every unit.
+func (st *Store) indexSnapshot(prev, s *Snapshot) error {
+	var previous *time.Time
+	if prev != nil {
+		previous = &prev.Taken
+	} else {
+		prev = &Snapshot{}
+	}
+	d := Compare(prev, s)
+	for _, ir := range d.Added {
+		if err := st.addEvent(ir.SerialNumber, Event{Kind: Added, Time: s.Taken, Previous: previous, Record: ir}); err != nil {
+			return err
+		}
+	}
+	for _, c := range d.Changed {
+		ev := Event{Kind: Changed, Time: s.Taken, Previous: previous, Record: s.Records[c.SerialNumber], Fields: c.Fields}
+		if err := st.addEvent(c.SerialNumber, ev); err != nil {
+			return err
+		}
+	}
+	for _, ir := range d.Removed {
+		if err := st.addEvent(ir.SerialNumber, Event{Kind: Removed, Time: s.Taken, Previous: previous}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addEvent appends ev to the timeline of a unit, unless it is there already.
+func (st *Store) addEvent(serialNumber string, ev Event) error {
+	h, err := st.loadHistory(serialNumber)
+	if err != nil {
+		return err
+	}
+	if n := len(h.Events); n > 0 && !h.Events[n-1].Time.Before(ev.Time) {
+		return nil
+	}
+	h.Events = append(h.Events, ev)
+	return writeJSON(st.unitPath(serialNumber), h)
+}
+
+func (st *Store) loadHistory(serialNumber string) (*History, error) {
+	h := &History{SerialNumber: serialNumber, Events: []Event{}}
+	if _, err := readJSON(st.unitPath(serialNumber), h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
@@ -0,0 +1,103 @@
+package snapshot
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/expertviewtest"
+	"github.com/larixsource/go-expertview/export"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	unit1 = expertview.InstallationRecord{
+		SerialNumber: "296930501",
+		HardwareProf: "FLX12",
+		DCF:          "SQU-8000-TRKS-000000-131001CL.DCF",
+		Firmware:     "8000-01V114R048.BIN",
+	}
+	unit2 = expertview.InstallationRecord{
+		SerialNumber: "296930502",
+		DCF:          "SQU-8000-TRKS-000000-131002CL.DCF",
+		Firmware:     "8000-01V114R048.BIN",
+	}
+	unit3 = expertview.InstallationRecord{
+		SerialNumber: "296930503",
+		DCF:          "SQU-8000-TRKS-000000-131002CL.DCF",
+		Firmware:     "8000-01V115R049.BIN",
+	}
+	day1 = time.Date(2016, 8, 24, 10, 0, 0, 0, time.UTC)
+	day2 = time.Date(2016, 8, 25, 10, 0, 0, 0, time.UTC)
+)
+
+func TestStore(t *testing.T) {
+	st, err := NewStore(filepath.Join(t.TempDir(), "snapshots"))
+	require.Nil(t, err)
+
+	_, err = st.Latest()
+	assert.Equal(t, ErrNoSnapshot, err)
+
+	require.Nil(t, st.Save(New([]expertview.InstallationRecord{unit2, unit1}, day2)))
+	require.Nil(t, st.Save(New([]expertview.InstallationRecord{unit1}, day1)))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(st.Dir, "notes.json"), []byte("{}"), 0644))
+
+	times, err := st.List()
+	require.Nil(t, err)
+	assert.Equal(t, []time.Time{day1, day2}, times)
+
+	s, err := st.Latest()
+	require.Nil(t, err)
+	assert.Equal(t, day2, s.Taken)
+	assert.Equal(t, []string{"296930501", "296930502"}, s.SerialNumbers())
+	assert.Equal(t, unit2, s.Records["296930502"])
+
+	s, err = st.At(day2.Add(-time.Hour))
+	require.Nil(t, err)
+	assert.Equal(t, day1, s.Taken)
+
+	_, err = st.At(day1.Add(-time.Second))
+	assert.Equal(t, ErrNoSnapshot, err)
+}
+
+func TestTake(t *testing.T) {
+	fake := expertviewtest.NewFake()
+	fake.SetInstallationRecords([]expertview.InstallationRecord{unit1, unit2})
+
+	s, err := Take(context.Background(), fake)
+	require.Nil(t, err)
+	assert.Len(t, s.Records, 2)
+	assert.WithinDuration(t, time.Now(), s.Taken, time.Minute)
+}
+
+func TestCompare(t *testing.T) {
+	updated := unit1
+	updated.Firmware = "8000-01V115R049.BIN"
+	updated.HardwareProf = "FLX13"
+
+	d := Compare(
+		New([]expertview.InstallationRecord{unit1, unit2}, day1),
+		New([]expertview.InstallationRecord{unit3, updated}, day2),
+	)
+	assert.Equal(t, &Diff{
+		From:    day1,
+		To:      day2,
+		Added:   []expertview.InstallationRecord{unit3},
+		Removed: []expertview.InstallationRecord{unit2},
+		Changed: []Change{{
+			SerialNumber: "296930501",
+			Fields: []FieldChange{
+				{Field: export.HardwareProf, From: "FLX12", To: "FLX13"},
+				{Field: export.Firmware, From: "8000-01V114R048.BIN", To: "8000-01V115R049.BIN"},
+			},
+		}},
+	}, d)
+	assert.False(t, d.Empty())
+
+	s := New([]expertview.InstallationRecord{unit1}, day1)
+	assert.True(t, Compare(s, s).Empty())
+}
@@ -0,0 +1,165 @@
+// Package snapshot keeps timestamped copies of the installation records of a fleet on disk, and reports what
+// changed between them and the history of every unit.
+package snapshot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/larixsource/go-expertview"
+	"github.com/larixsource/go-expertview/internal/atomicfile"
+)
+
+// ErrNoSnapshot is returned when there is no snapshot for the requested time.
+var ErrNoSnapshot = errors.New("no snapshot")
+
+// Snapshot is the result of GetInstallationRecords at a given time, keyed by serial number.
+type Snapshot struct {
+	Taken   time.Time                                `json:"taken"`
+	Records map[string]expertview.InstallationRecord `json:"records"`
+}
+
+// New returns a snapshot of records taken at the given time. When serial numbers repeat, the last record wins.
+func New(records []expertview.InstallationRecord, taken time.Time) *Snapshot {
+	s := &Snapshot{
+		Taken:   taken.UTC(),
+		Records: make(map[string]expertview.InstallationRecord, len(records)),
+	}
+	for _, ir := range records {
+		s.Records[ir.SerialNumber] = ir
+	}
+	return s
+}
+
+// Take returns a snapshot of the installation records of c, taken now.
+func Take(ctx context.Context, c expertview.Client) (*Snapshot, error) {
+	records, err := c.GetInstallationRecordsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return New(records, time.Now()), nil
+}
+
+// SerialNumbers returns the serial numbers of the snapshot, sorted.
+func (s *Snapshot) SerialNumbers() []string {
+	sns := make([]string, 0, len(s.Records))
+	for sn := range s.Records {
+		sns = append(sns, sn)
+	}
+	sort.Strings(sns)
+	return sns
+}
+
+// timeLayout names the snapshot files, so they sort by time. Snapshots taken within the same second replace
+// each other.
+const timeLayout = "20060102T150405Z"
+
+// Store keeps snapshots as JSON files in a directory, one per snapshot, named after the time it was taken. The
+// history of every unit is indexed under the index/ subdirectory.
+type Store struct {
+	Dir string
+}
+
+// NewStore returns a Store in dir, creating it if needed.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Store{Dir: dir}, nil
+}
+
+func (st *Store) path(taken time.Time) string {
+	return filepath.Join(st.Dir, taken.UTC().Format(timeLayout)+".json")
+}
+
+// Save writes s to the store atomically.
+func (st *Store) Save(s *Snapshot) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(st.path(s.Taken), append(b, '\n'))
+}
+
+// List returns the times of the snapshots in the store, oldest first.
+func (st *Store) List() ([]time.Time, error) {
+	infos, err := ioutil.ReadDir(st.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var times []time.Time
+	for _, fi := range infos {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		t, err := time.Parse(timeLayout, strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			// not a snapshot
+			continue
+		}
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times, nil
+}
+
+// Load reads the snapshot taken at the given time, as returned by List.
+func (st *Store) Load(taken time.Time) (*Snapshot, error) {
+	s, _, err := st.load(taken)
+	return s, err
+}
+
+// load is like Load, also returning the SHA-256 of the snapshot file.
+func (st *Store) load(taken time.Time) (*Snapshot, string, error) {
+	b, err := ioutil.ReadFile(st.path(taken))
+	if os.IsNotExist(err) {
+		return nil, "", ErrNoSnapshot
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	s := &Snapshot{}
+	if err = json.Unmarshal(b, s); err != nil {
+		return nil, "", err
+	}
+	if s.Records == nil {
+		s.Records = make(map[string]expertview.InstallationRecord)
+	}
+	sum := sha256.Sum256(b)
+	return s, hex.EncodeToString(sum[:]), nil
+}
+
+// At returns the newest snapshot taken at or before t, or ErrNoSnapshot if there is none.
+func (st *Store) At(t time.Time) (*Snapshot, error) {
+	times, err := st.List()
+	if err != nil {
+		return nil, err
+	}
+	i := sort.Search(len(times), func(i int) bool { return times[i].After(t) })
+	if i == 0 {
+		return nil, ErrNoSnapshot
+	}
+	return st.Load(times[i-1])
+}
+
+// Latest returns the newest snapshot, or ErrNoSnapshot if the store is empty.
+func (st *Store) Latest() (*Snapshot, error) {
+	times, err := st.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(times) == 0 {
+		return nil, ErrNoSnapshot
+	}
+	return st.Load(times[len(times)-1])
+}
@@ -1,12 +1,26 @@
 package expertview
 
 import (
+	"bufio"
 	"encoding/base64"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 )
 
+func getFileFaultError(fault *soapFault) error {
+	detail := fault.Detail
+	switch {
+	case detail != nil && detail.FirmwareNotSelectableException != nil:
+		return newFaultError(fault, ErrFirmwareNotSelectable)
+	case detail != nil && detail.NoSuchFileException != nil:
+		return newFaultError(fault, ErrNoSuchFile)
+	default:
+		return faultError(fault)
+	}
+}
+
 func parseGetFile(r []byte) ([]byte, error) {
 	env := &soapEnvelope{}
 	err := xml.Unmarshal(r, env)
@@ -16,19 +30,7 @@ func parseGetFile(r []byte) ([]byte, error) {
 
 	fault := env.Body.Fault
 	if fault != nil {
-		detail := fault.Detail
-		switch {
-		case detail != nil && detail.AuthenticationException != nil:
-			return nil, ErrAuthentication
-		case detail != nil && detail.UnexpectedException != nil:
-			return nil, ErrUnexpected
-		case detail != nil && detail.FirmwareNotSelectableException != nil:
-			return nil, ErrFirmwareNotSelectable
-		case detail != nil && detail.NoSuchFileException != nil:
-			return nil, ErrAuthentication
-		default:
-			return nil, errors.New("unknown error")
-		}
+		return nil, getFileFaultError(fault)
 	}
 
 	soapGetFile := env.Body.GetFileResponse
@@ -48,3 +50,83 @@ func parseGetFile(r []byte) ([]byte, error) {
 	}
 	return buf[:n], nil
 }
+
+// streamGetFile decodes a getFile response read from r, writing the file contents to w as the base64 text of
+// <return> arrives. The xml decoder reads r through a *bufio.Reader (an io.ByteReader, so the decoder does no
+// buffering of its own) and is only used to find the <return> start tag; its text is then read straight from the
+// bufio.Reader, so it is never held in memory as a whole.
+func streamGetFile(r io.Reader, w io.Writer) (int64, error) {
+	br := bufio.NewReader(r)
+	d := xml.NewDecoder(br)
+	inResponse := false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF && inResponse {
+			return 0, errors.New("empty getFileResponse")
+		}
+		if err == io.EOF {
+			return 0, errors.New("getFileResponse not found")
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch {
+		case se.Name.Space == soapEnvelopeNS && se.Name.Local == "Fault":
+			fault := &soapFault{}
+			err = d.DecodeElement(fault, &se)
+			if err != nil {
+				return 0, err
+			}
+			return 0, getFileFaultError(fault)
+		case se.Name.Space == expertViewNS && se.Name.Local == "getFileResponse":
+			inResponse = true
+		case inResponse && se.Name.Local == "return":
+			n, err := io.Copy(w, base64.NewDecoder(base64.StdEncoding, &charDataReader{r: br}))
+			switch {
+			case err != nil:
+				return n, fmt.Errorf("error decoding getFileResponse b64 data: %s", err)
+			case n == 0:
+				return 0, errors.New("empty getFileResponse")
+			}
+			return n, nil
+		}
+	}
+}
+
+// charDataReader reads the text of an element up to the next markup, dropping the whitespace a base64 decoder
+// does not skip by itself.
+type charDataReader struct {
+	r    *bufio.Reader
+	done bool
+}
+
+func (cr *charDataReader) Read(p []byte) (int, error) {
+	if cr.done {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) {
+		b, err := cr.r.ReadByte()
+		if err == io.EOF {
+			return n, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return n, err
+		}
+		switch b {
+		case '<':
+			cr.done = true
+			return n, io.EOF
+		case ' ', '\t':
+			continue
+		}
+		p[n] = b
+		n++
+	}
+	return n, nil
+}
@@ -34,15 +34,7 @@ func parseGetFileList(r []byte) (FileList, error) {
 
 	fault := env.Body.Fault
 	if fault != nil {
-		detail := fault.Detail
-		switch {
-		case detail != nil && detail.AuthenticationException != nil:
-			return FileList{}, ErrAuthentication
-		case detail != nil && detail.UnexpectedException != nil:
-			return FileList{}, ErrUnexpected
-		default:
-			return FileList{}, errors.New("unknown error")
-		}
+		return FileList{}, faultError(fault)
 	}
 
 	soapGetFileList := env.Body.GetFileListResponse
@@ -34,15 +34,7 @@ func parseGetInstallRecords(r []byte) ([]InstallationRecord, error) {
 
 	fault := env.Body.Fault
 	if fault != nil {
-		detail := fault.Detail
-		switch {
-		case detail != nil && detail.AuthenticationException != nil:
-			return nil, ErrAuthentication
-		case detail != nil && detail.UnexpectedException != nil:
-			return nil, ErrUnexpected
-		default:
-			return nil, errors.New("unknown error")
-		}
+		return nil, faultError(fault)
 	}
 
 	soapGetInstallRecords := env.Body.GetInstallRecordsResponse
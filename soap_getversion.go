@@ -0,0 +1,29 @@
+package expertview
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+)
+
+func parseGetVersion(r []byte) (Version, error) {
+	env := &soapEnvelope{}
+	err := xml.Unmarshal(r, env)
+	if err != nil {
+		return Version{}, err
+	}
+
+	fault := env.Body.Fault
+	if fault != nil {
+		return Version{}, faultError(fault)
+	}
+
+	soapGetVersion := env.Body.GetVersionResponse
+	switch {
+	case soapGetVersion == nil:
+		return Version{}, errors.New("getVersionResponse not found")
+	case strings.TrimSpace(soapGetVersion.Return) == "":
+		return Version{}, errors.New("empty getVersionResponse")
+	}
+	return ParseVersion(soapGetVersion.Return)
+}
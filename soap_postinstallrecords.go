@@ -0,0 +1,53 @@
+package expertview
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+// InstallRecordsPayload returns the INSTALLATIONS XML document for the given records, as plain XML.
+// PostInstallRecords base64-encodes it into the <records> element of its request. It holds no credentials, so it
+// can be shown to check what would be posted.
+func InstallRecordsPayload(records []InstallationRecord) ([]byte, error) {
+	xmlRecords := installRecordsXml{
+		Records: make([]installRecordXml, 0, len(records)),
+	}
+	for _, rec := range records {
+		xmlRecords.Records = append(xmlRecords.Records, installRecordXml{
+			SN:           rec.SerialNumber,
+			ID:           rec.ID,
+			Telematic:    rec.Telematic,
+			HardwareProf: rec.HardwareProf,
+			SoftwareProf: rec.SoftwareProf,
+			DCF:          rec.DCF,
+			Firmware:     rec.Firmware,
+			Key:          rec.Key,
+			Username:     rec.Username,
+		})
+	}
+
+	b, err := xml.Marshal(xmlRecords)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding postInstallRecords xml data: %s", err)
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+func parsePostInstallRecords(r []byte) error {
+	env := &soapEnvelope{}
+	err := xml.Unmarshal(r, env)
+	if err != nil {
+		return err
+	}
+
+	fault := env.Body.Fault
+	if fault != nil {
+		return faultError(fault)
+	}
+
+	if env.Body.PostInstallRecordsResponse == nil {
+		return errors.New("postInstallRecordsResponse not found")
+	}
+	return nil
+}
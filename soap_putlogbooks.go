@@ -0,0 +1,131 @@
+package expertview
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const logBookTimeLayout = "2006/01/02 15:04:05"
+
+type logBooksXml struct {
+	XMLName  xml.Name     `xml:"LOGBOOKS"`
+	LogBooks []logBookXml `xml:"LOGBOOK"`
+}
+
+type logBookXml struct {
+	SN      string            `xml:"SN,attr"`
+	Driver  string            `xml:"DRIVER,omitempty"`
+	Vehicle string            `xml:"VEHICLE,omitempty"`
+	Entries []logBookEntryXml `xml:"ENTRIES>ENTRY"`
+}
+
+type logBookEntryXml struct {
+	Activity      string `xml:"ACTIVITY,attr"`
+	Start         string `xml:"START"`
+	End           string `xml:"END"`
+	StartOdometer string `xml:"STARTODOMETER,omitempty"`
+	EndOdometer   string `xml:"ENDODOMETER,omitempty"`
+	Description   string `xml:"DESCRIPTION,omitempty"`
+}
+
+type logBookResultsXml struct {
+	XMLName xml.Name           `xml:"RESULTS"`
+	Results []logBookResultXml `xml:"RESULT"`
+}
+
+type logBookResultXml struct {
+	SN       string `xml:"SN,attr"`
+	Accepted int    `xml:"ACCEPTED"`
+	Rejected int    `xml:"REJECTED"`
+	Message  string `xml:"MESSAGE"`
+}
+
+func formatOdometer(km float64) string {
+	if km == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(km, 'f', -1, 64)
+}
+
+func encodeLogBooks(logBooks []LogBook) ([]byte, error) {
+	xmlLogBooks := logBooksXml{
+		LogBooks: make([]logBookXml, 0, len(logBooks)),
+	}
+	for _, lb := range logBooks {
+		entries := make([]logBookEntryXml, 0, len(lb.Entries))
+		for _, e := range lb.Entries {
+			entries = append(entries, logBookEntryXml{
+				Activity:      string(e.Activity),
+				Start:         e.Start.UTC().Format(logBookTimeLayout),
+				End:           e.End.UTC().Format(logBookTimeLayout),
+				StartOdometer: formatOdometer(e.StartOdometer),
+				EndOdometer:   formatOdometer(e.EndOdometer),
+				Description:   e.Description,
+			})
+		}
+		xmlLogBooks.LogBooks = append(xmlLogBooks.LogBooks, logBookXml{
+			SN:      lb.SerialNumber,
+			Driver:  lb.Driver,
+			Vehicle: lb.Vehicle,
+			Entries: entries,
+		})
+	}
+
+	b, err := xml.Marshal(xmlLogBooks)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding putLogBooks xml data: %s", err)
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+func parsePutLogBooks(r []byte) ([]LogBookResult, error) {
+	env := &soapEnvelope{}
+	err := xml.Unmarshal(r, env)
+	if err != nil {
+		return nil, err
+	}
+
+	fault := env.Body.Fault
+	if fault != nil {
+		return nil, faultError(fault)
+	}
+
+	soapPutLogBooks := env.Body.PutLogBooksResponse
+	switch {
+	case soapPutLogBooks == nil:
+		return nil, errors.New("putLogBooksResponse not found")
+	case len(soapPutLogBooks.Return) == 0:
+		return nil, errors.New("empty putLogBooksResponse")
+	}
+
+	// decode return (base64)
+	dl := base64.StdEncoding.DecodedLen(len(soapPutLogBooks.Return))
+	buf := make([]byte, dl)
+	n, decErr := base64.StdEncoding.Decode(buf, soapPutLogBooks.Return)
+	if decErr != nil {
+		return nil, fmt.Errorf("error decoding putLogBooksResponse b64 data: %s", decErr)
+	}
+
+	// decode xml
+	var xmlResults logBookResultsXml
+	decErr = xml.NewDecoder(bytes.NewReader(buf[:n])).Decode(&xmlResults)
+	if decErr != nil {
+		return nil, fmt.Errorf("error decoding putLogBooksResponse xml data: %s", decErr)
+	}
+
+	// to []LogBookResult
+	results := make([]LogBookResult, 0, len(xmlResults.Results))
+	for _, res := range xmlResults.Results {
+		results = append(results, LogBookResult{
+			SerialNumber: res.SN,
+			Accepted:     res.Accepted,
+			Rejected:     res.Rejected,
+			Message:      res.Message,
+		})
+	}
+	return results, nil
+}
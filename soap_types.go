@@ -2,6 +2,11 @@ package expertview
 
 import "encoding/xml"
 
+const (
+	soapEnvelopeNS = "http://schemas.xmlsoap.org/soap/envelope/"
+	expertViewNS   = "http://webservice.expertview.squarell.com/"
+)
+
 type soapEnvelope struct {
 	XMLName xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"`
 
@@ -11,10 +16,13 @@ type soapEnvelope struct {
 type soapBody struct {
 	XMLName xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body"`
 
-	Fault                     *soapFault                 `xml:",omitempty"`
-	GetFileListResponse       *getFileListResponse       `xml:"getFileListResponse,omitempty"`
-	GetFileResponse           *getFileResponse           `xml:"getFileResponse,omitempty"`
-	GetInstallRecordsResponse *getInstallRecordsResponse `xml:"getInstallRecordsResponse,omitempty"`
+	Fault                      *soapFault                  `xml:",omitempty"`
+	GetFileListResponse        *getFileListResponse        `xml:"getFileListResponse,omitempty"`
+	GetFileResponse            *getFileResponse            `xml:"getFileResponse,omitempty"`
+	GetInstallRecordsResponse  *getInstallRecordsResponse  `xml:"getInstallRecordsResponse,omitempty"`
+	PostInstallRecordsResponse *postInstallRecordsResponse `xml:"postInstallRecordsResponse,omitempty"`
+	GetVersionResponse         *getVersionResponse         `xml:"getVersionResponse,omitempty"`
+	PutLogBooksResponse        *putLogBooksResponse        `xml:"putLogBooksResponse,omitempty"`
 }
 
 type soapFault struct {
@@ -31,6 +39,14 @@ type soapDetail struct {
 	UnexpectedException            *unexpectedException            `xml:"UnexpectedException,omitempty"`
 	FirmwareNotSelectableException *firmwareNotSelectableException `xml:"FirmwareNotSelectableException,omitempty"`
 	NoSuchFileException            *noSuchFileException            `xml:"NoSuchFileException,omitempty"`
+	Other                          []otherException                `xml:",any"`
+}
+
+// otherException holds any fault detail not known by soapDetail.
+type otherException struct {
+	XMLName xml.Name
+
+	Message string `xml:"message,omitempty"`
 }
 
 type authenticationException struct {
@@ -74,3 +90,21 @@ type getInstallRecordsResponse struct {
 
 	Return []byte `xml:"return"`
 }
+
+type postInstallRecordsResponse struct {
+	XMLName xml.Name `xml:"http://webservice.expertview.squarell.com/ postInstallRecordsResponse"`
+
+	Return []byte `xml:"return"`
+}
+
+type getVersionResponse struct {
+	XMLName xml.Name `xml:"http://webservice.expertview.squarell.com/ getVersionResponse"`
+
+	Return string `xml:"return"`
+}
+
+type putLogBooksResponse struct {
+	XMLName xml.Name `xml:"http://webservice.expertview.squarell.com/ putLogBooksResponse"`
+
+	Return []byte `xml:"return"`
+}
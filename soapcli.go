@@ -1,47 +1,185 @@
 package expertview
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/xml"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 )
 
 const defaultTimeout = 10 * time.Second
 
+const (
+	defaultMaxIdleConns    = 4
+	defaultIdleConnTimeout = 90 * time.Second
+)
+
 type soapCli struct {
-	Timeout            time.Duration
-	Endpoint           string
+	// Timeout is the dial timeout used by the default transport.
+	Timeout time.Duration
+	// CallTimeout, when set, bounds every call, from dial to the end of the response body.
+	CallTimeout time.Duration
+	Endpoint    string
+
+	// Client and Transport replace the default transport. Client takes precedence over Transport, and the TLS
+	// and idle pool settings below are only used by the default transport.
+	Client    *http.Client
+	Transport http.RoundTripper
+
 	InsecureSkipVerify bool
+	RootCAs            *x509.CertPool
+	Certificates       []tls.Certificate
+
+	// MaxIdleConns and IdleConnTimeout size the pool of keep-alive connections of the default transport.
+	MaxIdleConns    int
+	IdleConnTimeout time.Duration
+
+	once   sync.Once
+	client *http.Client
 }
 
-func (sc *soapCli) call(r io.Reader) ([]byte, error) {
-	req, err := http.NewRequest("POST", sc.Endpoint, r)
-	req.Header.Add("Content-Type", "text/xml; charset=\"utf-8\"")
-	req.Header.Set("User-Agent", "go-expertview/0.1")
-	req.Close = true
+// httpClient returns the client used by every call. The default transport is built on first use and then
+// shared, so connections are kept alive between calls.
+func (sc *soapCli) httpClient() *http.Client {
+	sc.once.Do(func() {
+		switch {
+		case sc.Client != nil:
+			sc.client = sc.Client
+		case sc.Transport != nil:
+			sc.client = &http.Client{Transport: sc.Transport}
+		default:
+			sc.client = &http.Client{Transport: sc.newTransport()}
+		}
+	})
+	return sc.client
+}
+
+func (sc *soapCli) newTransport() *http.Transport {
+	timeout := sc.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	maxIdle := sc.MaxIdleConns
+	if maxIdle == 0 {
+		maxIdle = defaultMaxIdleConns
+	}
+	idleTimeout := sc.IdleConnTimeout
+	if idleTimeout == 0 {
+		idleTimeout = defaultIdleConnTimeout
+	}
 
-	tr := &http.Transport{
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: sc.InsecureSkipVerify,
+			RootCAs:            sc.RootCAs,
+			Certificates:       sc.Certificates,
 		},
-		Dial: func(network, addr string) (net.Conn, error) {
-			timeout := sc.Timeout
-			if timeout == 0 {
-				timeout = defaultTimeout
-			}
-			return net.DialTimeout(network, addr, timeout)
-		},
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConns:        maxIdle,
+		MaxIdleConnsPerHost: maxIdle,
+		IdleConnTimeout:     idleTimeout,
+	}
+}
+
+func (sc *soapCli) closeIdleConnections() {
+	sc.httpClient().CloseIdleConnections()
+}
+
+func (sc *soapCli) call(ctx context.Context, r io.Reader) ([]byte, error) {
+	body, err := sc.stream(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return ioutil.ReadAll(body)
+}
+
+// stream posts r and returns the response body, which must be closed by the caller.
+func (sc *soapCli) stream(ctx context.Context, r io.Reader) (io.ReadCloser, error) {
+	cancel := context.CancelFunc(func() {})
+	if sc.CallTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, sc.CallTimeout)
 	}
 
-	client := &http.Client{Transport: tr}
-	res, err := client.Do(req)
+	req, err := http.NewRequestWithContext(ctx, "POST", sc.Endpoint, r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "text/xml; charset=\"utf-8\"")
+	req.Header.Set("User-Agent", "go-expertview/0.1")
+
+	res, err := sc.httpClient().Do(req)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
-	defer res.Body.Close()
+	body, err := checkResponse(res)
+	if err != nil {
+		res.Body.Close()
+		cancel()
+		return nil, err
+	}
+	return &cancelBody{ReadCloser: body, cancel: cancel}, nil
+}
+
+// maxFaultBody is the most of a non-2xx response body read looking for a SOAP fault.
+const maxFaultBody = 1 << 20
+
+// checkResponse returns an *HTTPError unless res holds a SOAP message: a 2xx response or a SOAP fault, with an xml
+// content type. Otherwise it returns the body to read the message from.
+func checkResponse(res *http.Response) (io.ReadCloser, error) {
+	ok := res.StatusCode >= 200 && res.StatusCode < 300
+	if ok && isXMLContentType(res.Header.Get("Content-Type")) {
+		return res.Body, nil
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(res.Body, maxFaultBody))
+	if err != nil {
+		return nil, err
+	}
+	if !ok && isXMLContentType(res.Header.Get("Content-Type")) {
+		env := &soapEnvelope{}
+		if xml.Unmarshal(b, env) == nil && env.Body.Fault != nil {
+			res.Body.Close()
+			return ioutil.NopCloser(bytes.NewReader(b)), nil
+		}
+	}
+
+	if len(b) > maxHTTPErrorBody {
+		b = b[:maxHTTPErrorBody]
+	}
+	return nil, &HTTPError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Header:     res.Header,
+		Body:       b,
+	}
+}
+
+// cancelBody releases the call context once the response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
 
-	return ioutil.ReadAll(res.Body)
+func (cb *cancelBody) Close() error {
+	err := cb.ReadCloser.Close()
+	cb.cancel()
+	return err
 }
@@ -0,0 +1,77 @@
+package expertview
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is an Expert View API version, in the major.minor.patch form used by DefaultVersion.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion parses a version string like "2.5.0". Missing minor or patch components are taken as zero.
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		nums[i] = n
+	}
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is lower than, equal to or greater than o.
+func (v Version) Compare(o Version) int {
+	switch {
+	case v.Major != o.Major:
+		return cmpInt(v.Major, o.Major)
+	case v.Minor != o.Minor:
+		return cmpInt(v.Minor, o.Minor)
+	default:
+		return cmpInt(v.Patch, o.Patch)
+	}
+}
+
+// Less reports whether v is lower than o.
+func (v Version) Less(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// Equal reports whether v and o are the same version.
+func (v Version) Equal(o Version) bool {
+	return v.Compare(o) == 0
+}
+
+// Newer reports whether v is greater than o.
+func (v Version) Newer(o Version) bool {
+	return v.Compare(o) > 0
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -1,143 +1,134 @@
 package expertview
 
 import (
-	"github.com/lestrrat/go-libxml2/dom"
-	"github.com/lestrrat/go-libxml2/types"
+	"encoding/base64"
+	"encoding/xml"
+	"strings"
 )
 
-func createGetFileList(cred Credentials, version string) (*dom.Document, error) {
-	doc, _, body, err := createEnvelope()
-	if err != nil {
-		return doc, err
-	}
-	node, err := createBaseNode(doc, "getFileList", cred, version)
-	if err != nil {
-		return doc, err
-	}
-	body.AddChild(node)
-	return doc, nil
+const xmlDeclaration = `<?xml version="1.0" encoding="utf-8"?>` + "\n"
+
+// element is a node of an outgoing SOAP message. Names are written as given, prefix included, and elements
+// without children nor text are written as empty-element tags.
+type element struct {
+	name     string
+	attrs    [][2]string
+	text     string
+	children []*element
 }
 
-func createGetFile(cred Credentials, version string, filename string) (*dom.Document, error) {
-	doc, _, body, err := createEnvelope()
-	if err != nil {
-		return doc, err
-	}
-	node, err := createBaseNode(doc, "getFile", cred, version)
-	if err != nil {
-		return doc, err
-	}
+func newElement(name string) *element {
+	return &element{name: name}
+}
 
-	filenameNode, err := doc.CreateElement("filename")
-	if err != nil {
-		return doc, err
-	}
-	err = filenameNode.AppendText(filename)
-	if err != nil {
-		return doc, err
-	}
-	err = node.AddChild(filenameNode)
-	if err != nil {
-		return doc, err
-	}
+func newTextElement(name, text string) *element {
+	return &element{name: name, text: text}
+}
 
-	body.AddChild(node)
-	return doc, nil
+func (e *element) setAttr(name, value string) {
+	e.attrs = append(e.attrs, [2]string{name, value})
 }
 
-func createGetInstallRecords(cred Credentials, version string) (*dom.Document, error) {
-	doc, _, body, err := createEnvelope()
-	if err != nil {
-		return doc, err
-	}
-	node, err := createBaseNode(doc, "getInstallRecords", cred, version)
-	if err != nil {
-		return doc, err
-	}
-	body.AddChild(node)
-	return doc, nil
+func (e *element) addChild(child *element) {
+	e.children = append(e.children, child)
 }
 
-func createEnvelope() (doc *dom.Document, header types.Element, body types.Element, err error) {
-	doc = dom.CreateDocument()
+func (e *element) writeTo(sb *strings.Builder) {
+	sb.WriteByte('<')
+	sb.WriteString(e.name)
+	for _, attr := range e.attrs {
+		sb.WriteByte(' ')
+		sb.WriteString(attr[0])
+		sb.WriteString(`="`)
+		xml.EscapeText(sb, []byte(attr[1]))
+		sb.WriteByte('"')
+	}
+	if e.text == "" && len(e.children) == 0 {
+		sb.WriteString("/>")
+		return
+	}
+	sb.WriteByte('>')
+	xml.EscapeText(sb, []byte(e.text))
+	for _, child := range e.children {
+		child.writeTo(sb)
+	}
+	sb.WriteString("</")
+	sb.WriteString(e.name)
+	sb.WriteByte('>')
+}
 
-	envelope, err := doc.CreateElementNS("http://schemas.xmlsoap.org/soap/envelope/", "S:Envelope")
-	if err != nil {
-		return
-	}
-	err = envelope.SetAttribute("xmlns:sq", "http://webservice.expertview.squarell.com/")
-	if err != nil {
-		return
-	}
+// document is an outgoing SOAP message.
+type document struct {
+	root *element
+}
 
-	header, err = doc.CreateElement("S:Header")
-	if err != nil {
-		return
-	}
-	err = envelope.AddChild(header)
-	if err != nil {
-		return
-	}
+func (d *document) String() string {
+	var sb strings.Builder
+	sb.WriteString(xmlDeclaration)
+	d.root.writeTo(&sb)
+	sb.WriteByte('\n')
+	return sb.String()
+}
 
-	body, err = doc.CreateElement("S:Body")
-	if err != nil {
-		return
-	}
-	err = envelope.AddChild(body)
-	if err != nil {
-		return
-	}
+func createGetFileList(cred Credentials, version string) *document {
+	doc, _, body := createEnvelope()
+	body.addChild(createBaseNode("getFileList", cred, version))
+	return doc
+}
 
-	err = doc.SetDocumentElement(envelope)
-	if err != nil {
-		return
-	}
-	return
+func createGetFile(cred Credentials, version string, filename string) *document {
+	doc, _, body := createEnvelope()
+	node := createBaseNode("getFile", cred, version)
+	node.addChild(newTextElement("filename", filename))
+	body.addChild(node)
+	return doc
 }
 
-func createBaseNode(doc *dom.Document, name string, cred Credentials, version string) (node types.Element, err error) {
-	node, err = doc.CreateElement("sq:" + name)
-	if err != nil {
-		return
-	}
+func createPutLogBooks(cred Credentials, version string, logBooks []byte) *document {
+	doc, _, body := createEnvelope()
+	node := createBaseNode("putLogBooks", cred, version)
+	node.addChild(newTextElement("logbooks", base64.StdEncoding.EncodeToString(logBooks)))
+	body.addChild(node)
+	return doc
+}
 
-	loginNode, err := doc.CreateElement("login")
-	if err != nil {
-		return
-	}
-	err = loginNode.AppendText(cred.Login)
-	if err != nil {
-		return
-	}
-	err = node.AddChild(loginNode)
-	if err != nil {
-		return
-	}
+func createGetInstallRecords(cred Credentials, version string) *document {
+	doc, _, body := createEnvelope()
+	body.addChild(createBaseNode("getInstallRecords", cred, version))
+	return doc
+}
 
-	passwordNode, err := doc.CreateElement("password")
-	if err != nil {
-		return
-	}
-	err = passwordNode.AppendText(cred.PasswordMD5Sum())
-	if err != nil {
-		return
-	}
-	err = node.AddChild(passwordNode)
-	if err != nil {
-		return
-	}
+func createPostInstallRecords(cred Credentials, version string, records []byte) *document {
+	doc, _, body := createEnvelope()
+	node := createBaseNode("postInstallRecords", cred, version)
+	node.addChild(newTextElement("records", base64.StdEncoding.EncodeToString(records)))
+	body.addChild(node)
+	return doc
+}
 
-	versionNode, err := doc.CreateElement("version")
-	if err != nil {
-		return
-	}
-	err = versionNode.AppendText(version)
-	if err != nil {
-		return
-	}
-	err = node.AddChild(versionNode)
-	if err != nil {
-		return
-	}
-	return
+func createGetVersion() *document {
+	doc, _, body := createEnvelope()
+	body.addChild(newElement("sq:getVersion"))
+	return doc
+}
+
+func createEnvelope() (doc *document, header *element, body *element) {
+	envelope := newElement("S:Envelope")
+	envelope.setAttr("xmlns:S", soapEnvelopeNS)
+	envelope.setAttr("xmlns:sq", expertViewNS)
+
+	header = newElement("S:Header")
+	envelope.addChild(header)
+	body = newElement("S:Body")
+	envelope.addChild(body)
+
+	return &document{root: envelope}, header, body
+}
+
+func createBaseNode(name string, cred Credentials, version string) *element {
+	node := newElement("sq:" + name)
+	node.addChild(newTextElement("login", cred.Login))
+	node.addChild(newTextElement("password", cred.PasswordMD5Sum()))
+	node.addChild(newTextElement("version", version))
+	return node
 }
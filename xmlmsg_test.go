@@ -0,0 +1,14 @@
+package expertview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateGetFile(t *testing.T) {
+	doc := createGetFile(Credentials{Login: "demo", Password: "demo"}, DefaultVersion, "a<b>&c.dcf")
+	assert.Equal(t, `<?xml version="1.0" encoding="utf-8"?>
+<S:Envelope xmlns:S="http://schemas.xmlsoap.org/soap/envelope/" xmlns:sq="http://webservice.expertview.squarell.com/"><S:Header/><S:Body><sq:getFile><login>demo</login><password>fe01ce2a7fbac8fafaed7c982a04e229</password><version>2.5.0</version><filename>a&lt;b&gt;&amp;c.dcf</filename></sq:getFile></S:Body></S:Envelope>
+`, doc.String())
+}